@@ -0,0 +1,197 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"gh_foundations/cmd/github_service"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/github"
+	"gh_foundations/internal/pkg/types/report"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const inventoryFormat = "inventory"
+
+var format string
+var output string
+var fromSnapshot string
+var excludeArchived bool
+var alertMaxAges map[string]int
+
+// Exit codes of the alerts command, the same as the check command
+const (
+	exitPassed     = 0
+	exitViolations = 1
+	exitError      = 2
+)
+
+var AlertsCmd = &cobra.Command{
+	Use:   "alerts [org-slug...]",
+	Short: "Inventory the open security alerts of the repositories.",
+	Long: `List the open Dependabot, code scanning and secret scanning alerts of every repository by severity and age.
+Open alerts older than the maximum age of their severity fail the rules of the SecurityAlerts profile.
+Use a report format such as json to write the results of the rules as check reports instead of the inventory.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 && fromSnapshot == "" {
+			return errors.New("requires a GitHub organization slug or --from-snapshot")
+		}
+		if _, ok := report.Formats[format]; !ok && format != inventoryFormat {
+			return fmt.Errorf("unsupported format %q", format)
+		}
+		return github.ValidateAlertMaxAges(alertMaxAges)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if code := runAlerts(cmd, args); code != exitPassed {
+			os.Exit(code)
+		}
+	},
+}
+
+func init() {
+	AlertsCmd.Flags().StringVarP(&format, "format", "f", inventoryFormat, "Output format. One of inventory, or json, sarif, junit, markdown or table to write check reports")
+	AlertsCmd.Flags().StringVarP(&output, "output", "o", "-", "Path of the output file, or - to write to stdout")
+	AlertsCmd.Flags().StringVar(&fromSnapshot, "from-snapshot", "", "Read the alerts from a snapshot file instead of the GitHub API. Inventories every organization of the snapshot when no slug is given")
+	AlertsCmd.Flags().BoolVar(&excludeArchived, "exclude-archived", false, "Skip archived repositories")
+	github_service.AddFlags(AlertsCmd.Flags())
+	AlertsCmd.Flags().StringToIntVar(&alertMaxAges, "alert-max-age", github.DefaultAlertMaxAges(), "Days an open alert of a severity may stay open, e.g. critical=30,high=90. Alerts of other severities never fail")
+}
+
+func runAlerts(cmd *cobra.Command, args []string) int {
+	var gs github.IGithubService
+	slugs := args
+	if fromSnapshot != "" {
+		snapshot, err := github.LoadSnapshot(fromSnapshot)
+		if err != nil {
+			cmd.PrintErrln(err)
+			return exitError
+		}
+		gs = &github.SnapshotService{Snapshot: snapshot}
+		if len(slugs) == 0 {
+			slugs = snapshot.Slugs()
+		}
+	} else {
+		var err error
+		if gs, err = github_service.NewGithubService(); err != nil {
+			cmd.PrintErrln(err)
+			return exitError
+		}
+	}
+
+	checkTypes := []types.CheckType{types.SecurityAlerts}
+	var errs error
+	var repos []github.Repository
+	// Organization of every repository
+	var organizations []string
+	var reports []types.CheckReport
+	for _, slug := range slugs {
+		orgRepos, err := gs.GetRepositories(slug, github.RepositoryListOptions{Type: "all", ExcludeArchived: excludeArchived, Alerts: true, AlertMaxAges: alertMaxAges}, nil)
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("unable to get repositories of %q: %w", slug, err))
+			continue
+		}
+		for _, r := range orgRepos {
+			repoReport := r.Check(types.DefaultRegistry, checkTypes)
+			repoReport.Organization = slug
+			reports = append(reports, repoReport)
+			organizations = append(organizations, slug)
+		}
+		repos = append(repos, orgRepos...)
+	}
+	if errs != nil {
+		cmd.PrintErrln(errs)
+	}
+
+	out := cmd.OutOrStdout()
+	if output != "-" {
+		file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			cmd.PrintErrln(err)
+			return exitError
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writeResults(out, organizations, repos, reports); err != nil {
+		cmd.PrintErrln(err)
+		return exitError
+	}
+
+	summary := report.Summarize(reports, types.DefaultRegistry)
+	cmd.PrintErrln(summary)
+	if errs != nil || summary.Errored > 0 {
+		return exitError
+	}
+	if summary.Total() > 0 {
+		return exitViolations
+	}
+	return exitPassed
+}
+
+func writeResults(w io.Writer, organizations []string, repos []github.Repository, reports []types.CheckReport) error {
+	if format != inventoryFormat {
+		writer, err := report.NewReportWriter(format, types.DefaultRegistry)
+		if err != nil {
+			return err
+		}
+		return writer.Write(w, reports)
+	}
+	return writeInventory(w, organizations, repos)
+}
+
+// Upper bounds in days of the age columns of the inventory. The last column holds the older alerts.
+var ageBuckets = []int{30, 90}
+
+// writeInventory writes one line per repository and tool with open alerts, counting them by severity and by age.
+// Tools whose alerts could not be listed are written with the error instead of counts. Ages are measured at the
+// time the repository was scanned, or captured in a snapshot.
+func writeInventory(w io.Writer, organizations []string, repos []github.Repository) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ORGANIZATION\tREPOSITORY\tTOOL\tCRITICAL\tHIGH\tMEDIUM\tLOW\t<=30D\t31-90D\t>90D\tOLDEST")
+	for i, repo := range repos {
+		alerts := repo.Alerts()
+		if alerts == nil {
+			continue
+		}
+		for _, tool := range github.AlertTools {
+			if message, ok := alerts.Errors[tool]; ok {
+				fmt.Fprintf(tw, "%s\t%s\t%s\terror: %s\n", organizations[i], repo.GetName(), tool, message)
+				continue
+			}
+			open := alerts.Open[tool]
+			if len(open) == 0 {
+				continue
+			}
+
+			severities := make(map[string]int)
+			ages := make([]int, len(ageBuckets)+1)
+			oldest := time.Duration(0)
+			for _, a := range open {
+				severities[a.Severity]++
+				age := a.Age(repo.ScannedAt())
+				oldest = max(oldest, age)
+				bucket := len(ageBuckets)
+				for b, days := range ageBuckets {
+					if age <= time.Duration(days)*24*time.Hour {
+						bucket = b
+						break
+					}
+				}
+				ages[bucket]++
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s", organizations[i], repo.GetName(), tool)
+			for _, severity := range github.AlertSeverities {
+				fmt.Fprintf(tw, "\t%d", severities[severity])
+			}
+			for _, count := range ages {
+				fmt.Fprintf(tw, "\t%d", count)
+			}
+			fmt.Fprintf(tw, "\t%dd\n", int(oldest.Hours()/24))
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,83 @@
+package check
+
+import (
+	"errors"
+	"gh_foundations/internal/pkg/types/baseline"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var baselineOutput string
+var justification string
+var expires string
+
+var BaselineCmd = &cobra.Command{
+	Use:   "baseline",
+	Short: "Manage the baseline of accepted check violations.",
+	Long:  `Manage the baseline file listing the check violations accepted as exceptions. Pass the file to "check --baseline" to suppress them.`,
+}
+
+var baselineCreateCmd = &cobra.Command{
+	Use:   "create [org-slug...]",
+	Short: "Create a baseline from the current violations.",
+	Long:  `Run the checks against organizations and write a baseline file suppressing every violation found.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := requireSlugs(args); err != nil {
+			return err
+		}
+		if expires != "" {
+			if _, err := time.Parse("2006-01-02", expires); err != nil {
+				return errors.New("--expires must be a date in the YYYY-MM-DD format")
+			}
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, registry, checkTypes, err := setupCheck()
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		slugs, err := orgSlugs(gs, args)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		if err := loadRepositoryInputs(); err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		reports, err := collectReports(gs, registry, slugs, checkTypes)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		file, err := os.OpenFile(baselineOutput, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+		defer file.Close()
+
+		b := baseline.FromReports(reports, justification, expires)
+		if err := b.Write(file); err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+		cmd.PrintErrf("Wrote %d suppressions to %s\n", len(b.Suppressions), baselineOutput)
+	},
+}
+
+func init() {
+	baselineCreateCmd.Flags().StringVarP(&baselineOutput, "output", "o", "baseline.yaml", "Path of the baseline file to write")
+	baselineCreateCmd.Flags().StringVar(&justification, "justification", "Accepted when the baseline was created", "Justification recorded for every suppression")
+	baselineCreateCmd.Flags().StringVar(&expires, "expires", "", "Expiry date of the suppressions in the YYYY-MM-DD format. Suppressions never expire when omitted")
+
+	BaselineCmd.AddCommand(baselineCreateCmd)
+}
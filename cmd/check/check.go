@@ -1,83 +1,461 @@
 package check
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
+	"gh_foundations/cmd/github_service"
+	"gh_foundations/internal/pkg/functions"
 	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/baseline"
+	"gh_foundations/internal/pkg/types/diff"
 	"gh_foundations/internal/pkg/types/github"
+	githubfoundations "gh_foundations/internal/pkg/types/github_foundations"
+	"gh_foundations/internal/pkg/types/notify"
+	"gh_foundations/internal/pkg/types/policy"
+	"gh_foundations/internal/pkg/types/report"
 	"os"
-	"os/exec"
-	"strings"
+	"slices"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
-var outputFile = "check_results.json"
+var outputFile = "check_results"
+var format string
+var output string
+var profiles []string
+var rules []string
+var disabledRules []string
+var policyFiles []string
+var failOn string
+var baselineFile string
+var fromSnapshot string
+var fromProviders string
+var enterprise string
+var projectsDir string
+var compareTo string
+var hygieneFile string
+var notifyFile string
+var repositoryListOptions github.RepositoryListOptions
+
+// Exit codes of the check command
+const (
+	ExitPassed     = 0
+	ExitViolations = 1
+	ExitError      = 2
+)
 
 var CheckCmd = &cobra.Command{
-	Use:   "check",
+	Use:   "check [org-slug...]",
 	Short: "Perform checks against a Github configuration.",
 	Long:  `Perform checks against a Github configuration and generate reports.`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) < 1 {
-			return errors.New("requires a GitHub organization slug")
+		if err := requireSlugs(args); err != nil {
+			return err
 		}
-		return nil
+		if _, ok := report.Formats[format]; !ok {
+			return fmt.Errorf("unsupported format %q", format)
+		}
+		if _, err := types.ParseSeverity(failOn); err != nil && failOn != "none" {
+			return fmt.Errorf("invalid --fail-on value: %w", err)
+		}
+		if err := validateDiffFormat(); err != nil {
+			return err
+		}
+		return validateFix()
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		var err error
-		reports := make([]types.CheckReport, 0)
-		slug := args[0]
-		authToken, set := os.LookupEnv("GITHUB_TOKEN")
-		if !set {
-			authToken, err = getTokenFromGhCli()
-			if err != nil {
-				cmd.PrintErr("GITHUB_TOKEN environment variable not set and unable to authenticate with gh cli")
-				return
-			}
+		if code := runCheck(cmd, args); code != ExitPassed {
+			os.Exit(code)
 		}
+	},
+}
 
-		gs := github.NewGithubService(authToken)
-		org, err := gs.GetOrganization(slug)
-		if err == nil {
-			reports = append(reports, org.Check([]types.CheckType{types.GoCGuardrails}))
+func init() {
+	CheckCmd.Flags().StringVarP(&format, "format", "f", "json", "Report format. One of json, sarif, junit, markdown or table")
+	CheckCmd.Flags().StringVarP(&output, "output", "o", "", "Path of the report file, or - to write to stdout. Defaults to check_results.<ext>")
+	CheckCmd.Flags().StringVar(&failOn, "fail-on", "low", "Exit with code 1 when a violation of at least this severity is found. One of low, medium, high, critical or none")
+	CheckCmd.Flags().StringVar(&baselineFile, "baseline", "", "Baseline file of accepted violations to suppress")
+	CheckCmd.Flags().StringVar(&compareTo, "compare-to", "", "Previous json report to compare the violations against. The diff is written to stderr")
+	CheckCmd.Flags().StringVar(&diffFormat, "diff-format", "table", "Format of the diff written by --compare-to. One of table, markdown or json")
+	CheckCmd.Flags().StringVar(&notifyFile, "notify", "", "Notification file of the webhook, slack and file sinks the results are pushed to. Violations are new relative to --compare-to")
+	CheckCmd.Flags().StringVar(&remediateHcl, "remediate-hcl", "", "Projects directory whose repository inputs are patched to fix the violations")
+	CheckCmd.Flags().StringVar(&remediationPatch, "remediation-patch", "remediation.patch", "Path of the patch written by --remediate-hcl, or - to write to stdout")
+	CheckCmd.Flags().BoolVar(&fix, "fix", false, "Apply the compliant value of the fixable rules through the GitHub API after asking for confirmation")
+	CheckCmd.Flags().BoolVarP(&fixYes, "yes", "y", false, "Apply the fixes without asking for confirmation")
+	CheckCmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "Print the API calls of the fixes without making them")
+	CheckCmd.Flags().StringVar(&changeLog, "change-log", "changes.jsonl", "File the change records of the applied fixes are appended to, one json object per line")
+	CheckCmd.PersistentFlags().StringSliceVarP(&profiles, "profile", "p", []string{types.GoCGuardrails}, "Check profiles to run")
+	CheckCmd.PersistentFlags().StringSliceVarP(&rules, "rules", "r", []string{}, "Only run the given rule ids. Overrides --profile")
+	CheckCmd.PersistentFlags().StringSliceVar(&disabledRules, "disable-rules", []string{}, "Rule ids to skip")
+	CheckCmd.PersistentFlags().StringVar(&repositoryListOptions.Type, "repo-type", "all", "Type of repositories to check. One of all, public, private, forks, sources or member")
+	CheckCmd.PersistentFlags().StringSliceVar(&repositoryListOptions.Visibilities, "visibility", []string{}, "Only check repositories with the given visibilities (public, private or internal)")
+	CheckCmd.PersistentFlags().BoolVar(&repositoryListOptions.ExcludeArchived, "exclude-archived", false, "Skip archived repositories")
+	CheckCmd.PersistentFlags().BoolVar(&repositoryListOptions.ExcludeForks, "exclude-forks", false, "Skip forked repositories")
+	CheckCmd.PersistentFlags().StringSliceVar(&policyFiles, "policy", []string{}, "Policy files declaring additional rules. Each policy is run as its own profile")
+	CheckCmd.PersistentFlags().StringVar(&fromProviders, "from-providers", "", "Also check every organization managed in the given organizations directory")
+	CheckCmd.PersistentFlags().StringVar(&enterprise, "enterprise", "", "Also check every organization of the given enterprise")
+	CheckCmd.PersistentFlags().StringVar(&projectsDir, "projects-dir", "", "Projects directory whose repository inputs list the protected_branches checked by the BranchProtection profile and the license_template checked by the RepositoryHygiene profile")
+	CheckCmd.PersistentFlags().StringVar(&hygieneFile, "hygiene-config", "", "YAML file listing the files the RepositoryHygiene profile requires in the repositories of each visibility")
+	CheckCmd.PersistentFlags().StringToIntVar(&repositoryListOptions.AlertMaxAges, "alert-max-age", github.DefaultAlertMaxAges(), "Days an open security alert of a severity may stay open before it fails the SecurityAlerts profile, e.g. critical=30,high=90")
+	github_service.AddFlags(CheckCmd.PersistentFlags())
+	CheckCmd.PersistentFlags().StringVar(&fromSnapshot, "from-snapshot", "", "Run the checks against a snapshot file instead of the GitHub API. Checks every organization of the snapshot when no slug is given")
+
+	CheckCmd.AddCommand(RulesCmd)
+	CheckCmd.AddCommand(BaselineCmd)
+	CheckCmd.AddCommand(DiffCmd)
+	CheckCmd.AddCommand(ServeCmd)
+}
+
+// Run the checks, write the report and return the exit code of the command.
+// Execution errors are reported but do not prevent the results that could be collected from being written.
+func runCheck(cmd *cobra.Command, args []string) int {
+	gs, registry, checkTypes, err := setupCheck()
+	if err != nil {
+		cmd.PrintErrln(err)
+		return ExitError
+	}
+
+	var suppressions *baseline.Baseline
+	if baselineFile != "" {
+		if suppressions, err = baseline.LoadBaseline(baselineFile); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
 		}
+	}
 
-		repos, err := gs.GetRepositories(slug, nil)
-		if err == nil {
-			for _, r := range repos {
-				reports = append(reports, r.Check([]types.CheckType{types.GoCGuardrails}))
-			}
+	// The previous report is read first as it may be overwritten by this run
+	var previous []types.CheckReport
+	if compareTo != "" {
+		if previous, err = diff.LoadReports(compareTo); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
 		}
+	}
+
+	var notifications *notify.Config
+	if notifyFile != "" {
+		if notifications, err = notify.LoadConfig(notifyFile); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
+		}
+		// Every violation is new without a previous report, so only_new would not filter anything
+		if notifications.OnlyNew() && compareTo == "" {
+			cmd.PrintErrln("sinks with only_new require --compare-to")
+			return ExitError
+		}
+	}
+
+	slugs, err := orgSlugs(gs, args)
+	if err != nil {
+		cmd.PrintErrln(err)
+		return ExitError
+	}
+	if err := loadRepositoryInputs(); err != nil {
+		cmd.PrintErrln(err)
+		return ExitError
+	}
+
+	reports, runErr := collectReports(gs, registry, slugs, checkTypes)
+	if runErr != nil {
+		cmd.PrintErrln(runErr)
+	}
+	if suppressions != nil {
+		suppressions.Apply(reports, time.Now())
+	}
 
-		file, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY, 0644)
+	if err := writeReports(cmd, registry, reports); err != nil {
+		cmd.PrintErrln(err)
+		return ExitError
+	}
+
+	if compareTo != "" {
+		if err := diff.Compare(previous, reports).Write(cmd.ErrOrStderr(), diffFormat); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
+		}
+	}
+	if remediateHcl != "" {
+		if err := writeRemediation(cmd, registry, reports); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
+		}
+	}
+	if fix {
+		if err := applyFixes(cmd, gs, registry, reports); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
+		}
+	}
+	if notifications != nil {
+		// Without a previous report every violation is new
+		if err := notifications.Notify(notify.NewNotification(previous, reports, registry)); err != nil {
+			cmd.PrintErrln(err)
+			return ExitError
+		}
+	}
+
+	summary := report.Summarize(reports, registry)
+	if len(slugs) > 1 {
+		for _, s := range report.SummarizeOrganizations(reports, registry) {
+			cmd.PrintErrf("%s: %s\n", s.Organization, s.Summary)
+		}
+	}
+	cmd.PrintErrln(summary)
+
+	if runErr != nil || summary.Errored > 0 {
+		return ExitError
+	}
+	if failOn != "none" {
+		threshold, _ := types.ParseSeverity(failOn)
+		if summary.ViolationsAtOrAbove(threshold) > 0 {
+			return ExitViolations
+		}
+	}
+	return ExitPassed
+}
+
+// An organization slug is required unless the organizations are discovered or read from a snapshot
+func requireSlugs(args []string) error {
+	if len(args) < 1 && fromSnapshot == "" && fromProviders == "" && enterprise == "" {
+		return errors.New("requires a GitHub organization slug, --from-providers, --enterprise or --from-snapshot")
+	}
+	return nil
+}
+
+// Resolve the checks to run along with the registry to run them against, and authenticate against GitHub or load
+// the snapshot to check
+func setupCheck() (github.IGithubService, *types.CheckRegistry, []types.CheckType, error) {
+	registry, checkTypes, err := selectCheckTypes()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if err := github.ValidateAlertMaxAges(repositoryListOptions.AlertMaxAges); err != nil {
+		return nil, nil, nil, err
+	}
+	if hygieneFile != "" {
+		if repositoryListOptions.HygieneRequirements, err = github.LoadHygieneRequirements(hygieneFile); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	if fromSnapshot != "" {
+		snapshot, err := github.LoadSnapshot(fromSnapshot)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			return nil, nil, nil, err
 		}
-		defer file.Close()
+		return &github.SnapshotService{Snapshot: snapshot}, registry, checkTypes, nil
+	}
+
+	gs, err := github_service.NewGithubService()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return gs, registry, checkTypes, nil
+}
+
+// The organizations to check: the given slugs, the organizations managed in the providers directory and the
+// organizations of the enterprise. Defaults to every organization of the snapshot when none are given.
+func orgSlugs(gs github.IGithubService, args []string) ([]string, error) {
+	slugs := slices.Clone(args)
+	if fromProviders != "" {
+		managed, err := functions.FindManagedOrgSlugs(fromProviders)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find the organizations managed in %q: %w", fromProviders, err)
+		}
+		slugs = append(slugs, managed...)
+	}
+	if enterprise != "" {
+		enterpriseSlugs, err := gs.GetEnterpriseOrganizations(enterprise)
+		if err != nil {
+			return nil, err
+		}
+		slugs = append(slugs, enterpriseSlugs...)
+	}
+	if s, ok := gs.(*github.SnapshotService); ok && len(slugs) == 0 {
+		return s.Snapshot.Slugs(), nil
+	}
 
-		bytes, err := json.Marshal(reports)
+	// Organizations found by several means are only checked once
+	unique := make([]string, 0, len(slugs))
+	for _, slug := range slugs {
+		if !slices.Contains(unique, slug) {
+			unique = append(unique, slug)
+		}
+	}
+	return unique, nil
+}
+
+// Read the protected_branches and license_template of every repository managed in the projects directory
+func loadRepositoryInputs() error {
+	if projectsDir == "" {
+		return nil
+	}
+	orgSet, err := functions.FindManagedRepos(projectsDir)
+	if err != nil {
+		return fmt.Errorf("unable to find the repositories managed in %q: %w", projectsDir, err)
+	}
+
+	repositoryListOptions.ProtectedBranches = make(map[string][]string)
+	repositoryListOptions.LicenseTemplates = make(map[string]string)
+	for org, projects := range orgSet.OrgProjectSets {
+		for _, repoSet := range projects.RepositorySets {
+			for _, repos := range [][]*githubfoundations.RepositoryInput{repoSet.PrivateRepositories, repoSet.PublicRepositories} {
+				for _, repo := range repos {
+					key := org + "/" + repo.Name
+					repositoryListOptions.ProtectedBranches[key] = append(repositoryListOptions.ProtectedBranches[key], repo.ProtectedBranches...)
+					if repo.LicenseTemplate != "" {
+						repositoryListOptions.LicenseTemplates[key] = repo.LicenseTemplate
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Check the organizations and their repositories. Errors fetching either are joined in the returned error.
+func collectReports(gs github.IGithubService, registry *types.CheckRegistry, slugs []string, checkTypes []types.CheckType) ([]types.CheckReport, error) {
+	var errs error
+	reports := make([]types.CheckReport, 0)
+	for _, slug := range slugs {
+		orgReports, err := collectOrgReports(gs, registry, slug, checkTypes)
+		errs = errors.Join(errs, err)
+		reports = append(reports, orgReports...)
+	}
+	return reports, errs
+}
+
+func collectOrgReports(gs github.IGithubService, registry *types.CheckRegistry, slug string, checkTypes []types.CheckType) ([]types.CheckReport, error) {
+	var errs error
+	reports := make([]types.CheckReport, 0)
+
+	org, err := gs.GetOrganization(slug, github.OrganizationOptions{ActionsSettings: github.NeedsOrganizationActions(registry, checkTypes)})
+	if err != nil {
+		errs = errors.Join(errs, fmt.Errorf("unable to get organization %q: %w", slug, err))
+	} else {
+		orgReport := org.Check(registry, checkTypes)
+		orgReport.Organization = slug
+		reports = append(reports, orgReport)
+	}
+
+	options := repositoryListOptions
+	options.BranchProtection = github.NeedsBranchProtection(registry, checkTypes)
+	options.ActionsSettings = github.NeedsRepositoryActions(registry, checkTypes)
+	options.Contents = github.NeedsRepositoryContents(registry, checkTypes)
+	options.Alerts = github.NeedsRepositoryAlerts(registry, checkTypes)
+	repos, err := gs.GetRepositories(slug, options, nil)
+	if err != nil {
+		errs = errors.Join(errs, fmt.Errorf("unable to get repositories of %q: %w", slug, err))
+	}
+	for _, r := range repos {
+		repoReport := r.Check(registry, checkTypes)
+		repoReport.Organization = slug
+		reports = append(reports, repoReport)
+	}
+
+	// Teams and members are only fetched when a selected profile checks them
+	if hasRulesFor(registry, checkTypes, github.TeamEntityType) {
+		teams, err := gs.GetTeams(slug)
 		if err != nil {
-			cmd.PrintErr(err)
-			return
+			errs = errors.Join(errs, fmt.Errorf("unable to get teams of %q: %w", slug, err))
 		}
+		for _, t := range teams {
+			teamReport := t.Check(registry, checkTypes)
+			teamReport.Organization = slug
+			reports = append(reports, teamReport)
+		}
+	}
+	if hasRulesFor(registry, checkTypes, github.MemberEntityType) {
+		members, err := gs.GetMembers(slug, github.MemberOptions{Activity: github.NeedsMemberActivity(registry, checkTypes)})
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("unable to get members of %q: %w", slug, err))
+		}
+		for _, m := range members {
+			memberReport := m.Check(registry, checkTypes)
+			memberReport.Organization = slug
+			reports = append(reports, memberReport)
+		}
+	}
 
-		file.Truncate(0)
-		file.Seek(0, 0)
-		file.Write(bytes)
-	},
+	return reports, errs
+}
+
+func hasRulesFor(registry *types.CheckRegistry, checkTypes []types.CheckType, entityType string) bool {
+	for _, t := range checkTypes {
+		if len(registry.RulesFor(t, entityType)) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
-func getTokenFromGhCli() (string, error) {
-	cmd, set := os.LookupEnv("GH_PATH")
-	if !set {
-		cmd = "gh"
+func writeReports(cmd *cobra.Command, registry *types.CheckRegistry, reports []types.CheckReport) error {
+	writer, err := report.NewReportWriter(format, registry)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if output != "-" {
+		path := output
+		if path == "" {
+			path = fmt.Sprintf("%s.%s", outputFile, report.Formats[format])
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
 	}
-	out, err := exec.Command(cmd, "auth", "token").Output()
+
+	return writer.Write(out, reports)
+}
+
+// Resolve the profiles and rule selection flags into the check types to run. The policies, the disabled rules and
+// the custom profile are registered in a copy of the default registry, which the checks are then run against.
+func selectCheckTypes() (*types.CheckRegistry, []types.CheckType, error) {
+	registry := types.DefaultRegistry.Clone()
+	policyCheckTypes, err := loadPolicies(registry)
 	if err != nil {
-		return "", errors.New("unable to authenticate with gh cli")
+		return nil, nil, err
+	}
+	if err := registry.Disable(disabledRules...); err != nil {
+		return nil, nil, err
+	}
+
+	if len(rules) > 0 {
+		if err := registry.RegisterProfile(types.CustomRules, rules...); err != nil {
+			return nil, nil, err
+		}
+		return registry, []types.CheckType{types.CustomRules}, nil
+	}
+
+	checkTypes := make([]types.CheckType, 0, len(profiles))
+	for _, p := range profiles {
+		checkType := types.CheckType(p)
+		if !registry.HasProfile(checkType) {
+			return nil, nil, fmt.Errorf("unknown check profile %q", p)
+		}
+		checkTypes = append(checkTypes, checkType)
 	}
+	return registry, append(checkTypes, policyCheckTypes...), nil
+}
 
-	return strings.TrimSpace(string(out)), nil
+// Register the rules of every policy file in the registry and return the profiles they define
+func loadPolicies(registry *types.CheckRegistry) ([]types.CheckType, error) {
+	checkTypes := make([]types.CheckType, 0, len(policyFiles))
+	for _, path := range policyFiles {
+		p, err := policy.LoadPolicy(path)
+		if err != nil {
+			return nil, err
+		}
+		checkType, err := p.Register(registry)
+		if err != nil {
+			return nil, fmt.Errorf("unable to register policy %q: %w", path, err)
+		}
+		checkTypes = append(checkTypes, checkType)
+	}
+	return checkTypes, nil
 }
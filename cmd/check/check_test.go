@@ -0,0 +1,87 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"gh_foundations/internal/pkg/types"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSnapshot = `{
+  "version": 2,
+  "organizations": [{
+    "organization": {"login": "org"},
+    "repositories": [{"repository": {"name": "api", "stargazers_count": 3}}],
+    "teams": [],
+    "members": []
+  }]
+}`
+
+// A numeric operator compared against a string can not be evaluated
+const testErroringPolicy = `name: Erroring
+rules:
+  - id: enough_stars
+    entity_type: github_repository
+    field: stargazers_count
+    operator: gt
+    value: many
+`
+
+func TestRunCheck_EvaluationErrorExitsWithError(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "snapshot.json"), []byte(testSnapshot), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.yaml"), []byte(testErroringPolicy), 0644))
+	fromSnapshot, policyFiles, profiles, output, format = filepath.Join(dir, "snapshot.json"), []string{filepath.Join(dir, "policy.yaml")}, []string{}, "-", "json"
+	t.Cleanup(func() {
+		fromSnapshot, policyFiles, profiles, output = "", []string{}, []string{types.GoCGuardrails}, ""
+	})
+
+	var stdout, stderr bytes.Buffer
+	CheckCmd.SetOut(&stdout)
+	CheckCmd.SetErr(&stderr)
+	t.Cleanup(func() {
+		CheckCmd.SetOut(nil)
+		CheckCmd.SetErr(nil)
+	})
+
+	assert.Equal(t, ExitError, runCheck(CheckCmd, nil))
+
+	var reports []types.CheckReport
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &reports))
+	assert.Len(t, reports, 2)
+	repoReport := reports[1]
+	assert.Equal(t, types.Errored, repoReport.Results["Erroring"])
+	assert.Empty(t, repoReport.Errors[0].Violations)
+	assert.Equal(t, map[string]string{"enough_stars": `operator "gt" requires a numeric value`}, repoReport.Errors[0].Unevaluated)
+	assert.Contains(t, stderr.String(), "1 passed, 0 failed, 1 errored")
+}
+
+func TestSelectCheckTypes_LeavesDefaultRegistryUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "policy.yaml"), []byte(testErroringPolicy), 0644))
+	policyFiles, rules, disabledRules = []string{filepath.Join(dir, "policy.yaml")}, []string{"enough_stars"}, []string{"secret_scanning"}
+	t.Cleanup(func() {
+		policyFiles, rules, disabledRules = []string{}, []string{}, []string{}
+	})
+
+	// Selecting the checks again registers the policy again
+	for i := 0; i < 2; i++ {
+		registry, checkTypes, err := selectCheckTypes()
+		assert.NoError(t, err)
+		assert.Equal(t, []types.CheckType{types.CustomRules}, checkTypes)
+		assert.Len(t, registry.RulesFor(types.CustomRules, "github_repository"), 1)
+	}
+
+	_, ok := types.DefaultRegistry.Rule("enough_stars")
+	assert.False(t, ok)
+	assert.False(t, types.DefaultRegistry.HasProfile(types.CustomRules))
+	var enabled []string
+	for _, rule := range types.DefaultRegistry.RulesFor(types.GoCGuardrails, "github_repository") {
+		enabled = append(enabled, rule.Id)
+	}
+	assert.Contains(t, enabled, "secret_scanning")
+}
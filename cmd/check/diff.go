@@ -0,0 +1,53 @@
+package check
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types/diff"
+	"os"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+var diffFormat string
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff <old-report> <new-report>",
+	Short: "Compare the violations of two check reports.",
+	Long: `Compare two reports written by check in the json format and list every violation as new, resolved or persisting.
+Violations are matched by entity and violation key.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(2)(cmd, args); err != nil {
+			return err
+		}
+		return validateDiffFormat()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		previous, err := diff.LoadReports(args[0])
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+		current, err := diff.LoadReports(args[1])
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		if err := diff.Compare(previous, current).Write(cmd.OutOrStdout(), diffFormat); err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+	},
+}
+
+func validateDiffFormat() error {
+	if !slices.Contains(diff.Formats, diffFormat) {
+		return fmt.Errorf("unsupported diff format %q", diffFormat)
+	}
+	return nil
+}
+
+func init() {
+	DiffCmd.Flags().StringVarP(&diffFormat, "format", "f", "table", "Format of the diff. One of table, markdown or json")
+}
@@ -0,0 +1,94 @@
+package check
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/github"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var fix bool
+var fixYes bool
+var fixDryRun bool
+var changeLog string
+
+// Validate the combination of the fix flags
+func validateFix() error {
+	if !fix && (fixYes || fixDryRun) {
+		return errors.New("--yes and --dry-run require --fix")
+	}
+	if fix && !fixDryRun && fromSnapshot != "" {
+		return errors.New("--fix cannot apply changes to a snapshot. Use --dry-run to print them")
+	}
+	return nil
+}
+
+// Apply the compliant value of the fixable rules violated in the reports once confirmed, and append a change record
+// of every fix to the change log. Dry runs only print the API calls.
+func applyFixes(cmd *cobra.Command, gs github.IGithubService, registry *types.CheckRegistry, reports []types.CheckReport) error {
+	fixes := github.PlanFixes(reports, registry)
+	if len(fixes) == 0 {
+		cmd.PrintErrln("No violation can be fixed through the GitHub API")
+		return nil
+	}
+
+	for _, f := range fixes {
+		cmd.PrintErrf("%s/%s %s: %s\n", f.Organization, f.EntityId, f.Rule, f)
+	}
+	if fixDryRun {
+		cmd.PrintErrf("Dry run: %d API calls were not made\n", len(fixes))
+		return nil
+	}
+
+	fixer, ok := gs.(github.IGithubFixer)
+	if !ok {
+		return errors.New("the checked service cannot apply fixes")
+	}
+	if !fixYes && !confirm(cmd, fmt.Sprintf("Apply %d changes to GitHub? [y/N] ", len(fixes))) {
+		cmd.PrintErrln("No change was applied")
+		return nil
+	}
+
+	file, err := os.OpenFile(changeLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open the change log: %w", err)
+	}
+	defer file.Close()
+	encoder := json.NewEncoder(file)
+
+	failed := 0
+	for _, f := range fixes {
+		record := github.ChangeRecord{Fix: f, Status: "applied"}
+		if err := fixer.ApplyFix(f); err != nil {
+			failed++
+			record.Status = "failed"
+			record.Error = err.Error()
+			cmd.PrintErrf("Unable to fix %s of %s/%s: %s\n", f.Rule, f.Organization, f.EntityId, err)
+		}
+		record.Timestamp = time.Now().Format(time.RFC3339)
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("unable to write the change log: %w", err)
+		}
+	}
+
+	cmd.PrintErrf("%d changes applied, %d failed. Changes were logged to %s\n", len(fixes)-failed, failed, changeLog)
+	if failed > 0 {
+		return fmt.Errorf("%d fixes failed", failed)
+	}
+	return nil
+}
+
+// Ask the question on stderr and read the answer from stdin
+func confirm(cmd *cobra.Command, question string) bool {
+	cmd.PrintErr(question)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,37 @@
+package check
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/functions"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/remediation"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var remediateHcl string
+var remediationPatch string
+
+// Write the patch of the HCL inputs fixing the violations of the reports and list the violations it cannot fix
+func writeRemediation(cmd *cobra.Command, registry *types.CheckRegistry, reports []types.CheckReport) error {
+	orgSet, err := functions.FindManagedRepos(remediateHcl)
+	if err != nil {
+		return fmt.Errorf("unable to find the repositories managed in %q: %w", remediateHcl, err)
+	}
+	plan := remediation.NewPlan(reports, orgSet, registry)
+
+	out := cmd.OutOrStdout()
+	if remediationPatch != "-" {
+		file, err := os.OpenFile(remediationPatch, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := plan.WritePatch(out); err != nil {
+		return err
+	}
+	return plan.WriteSummary(cmd.ErrOrStderr())
+}
@@ -0,0 +1,40 @@
+package check
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var RulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "List the available check rules.",
+	Long:  `List every registered check rule along with the profiles that include it and the benchmark controls it maps to.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// The rules of the policy files are listed along with the registered ones
+		registry := types.DefaultRegistry.Clone()
+		if _, err := loadPolicies(registry); err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tENTITY\tSEVERITY\tPROFILES\tCONTROLS\tDESCRIPTION")
+		for _, rule := range registry.Rules() {
+			profiles := registry.ProfilesFor(rule.Id)
+			var controls []string
+			for _, p := range profiles {
+				for _, c := range registry.Controls(p, rule.Id) {
+					controls = append(controls, c.Id)
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%v\t%s\t%s\n", rule.Id, rule.EntityType, rule.Severity, profiles, strings.Join(controls, ", "), rule.Description)
+		}
+		w.Flush()
+	},
+}
@@ -0,0 +1,95 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/baseline"
+	"gh_foundations/internal/pkg/types/server"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var listenAddress string
+var scanInterval time.Duration
+var serveBaselineFile string
+
+var ServeCmd = &cobra.Command{
+	Use:   "serve [org-slug...]",
+	Short: "Periodically run the checks and serve their results over HTTP.",
+	Long: `Run the checks against organizations at every interval and serve the results of the latest scan:
+  /reports           the reports of every entity, filtered by the organization and entity_type query parameters
+  /reports/<entity>  the reports of the entities with the given id
+  /metrics           the outcome of the rules and the duration of the scan in the Prometheus text format`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := requireSlugs(args); err != nil {
+			return err
+		}
+		if scanInterval <= 0 {
+			return errors.New("--interval must be positive")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, registry, checkTypes, err := setupCheck()
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+
+		var suppressions *baseline.Baseline
+		if serveBaselineFile != "" {
+			if suppressions, err = baseline.LoadBaseline(serveBaselineFile); err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(ExitError)
+			}
+		}
+
+		// The organizations are discovered again at every scan so that new ones are checked
+		scan := func() ([]types.CheckReport, error) {
+			slugs, err := orgSlugs(gs, args)
+			if err != nil {
+				return nil, err
+			}
+			if err := loadRepositoryInputs(); err != nil {
+				return nil, err
+			}
+			reports, err := collectReports(gs, registry, slugs, checkTypes)
+			if suppressions != nil {
+				suppressions.Apply(reports, time.Now())
+			}
+			return reports, err
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		s := server.NewServer(scan, registry)
+		go s.Run(ctx, scanInterval, func(err error) { cmd.PrintErrln(err) })
+
+		httpServer := &http.Server{Addr: listenAddress, Handler: s.Handler()}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			httpServer.Shutdown(shutdownCtx)
+		}()
+
+		cmd.PrintErrf("Serving the check results on %s\n", listenAddress)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			cmd.PrintErrln(err)
+			os.Exit(ExitError)
+		}
+	},
+}
+
+func init() {
+	ServeCmd.Flags().StringVar(&listenAddress, "listen", ":8080", "Address the HTTP server listens on")
+	ServeCmd.Flags().DurationVar(&scanInterval, "interval", time.Hour, "Interval between two scans")
+	ServeCmd.Flags().StringVar(&serveBaselineFile, "baseline", "", "Baseline file of accepted violations to suppress")
+}
@@ -0,0 +1,106 @@
+package github_service
+
+import (
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types/github"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+var githubUrl string
+var serviceOptions = github.DefaultServiceOptions()
+var appId int64
+var appInstallationId int64
+var appPrivateKeyPath string
+
+// AddFlags registers the flags configuring how commands connect to GitHub
+func AddFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&githubUrl, "github-url", os.Getenv("GITHUB_API_URL"), "Base URL of the GitHub API, e.g. https://github.example.com/api/v3 for GitHub Enterprise Server. Defaults to $GITHUB_API_URL or https://api.github.com")
+	flags.IntVar(&serviceOptions.Concurrency, "concurrency", serviceOptions.Concurrency, "Number of GitHub API requests made in parallel")
+	flags.IntVar(&serviceOptions.MaxRetries, "max-retries", serviceOptions.MaxRetries, "Number of times a rate limited or failed GitHub API request is retried")
+	flags.DurationVar(&serviceOptions.RequestTimeout, "request-timeout", serviceOptions.RequestTimeout, "Timeout of a single attempt of a GitHub API request. Waits for rate limits to reset are not included")
+	flags.Int64Var(&appId, "app-id", envInt64("GITHUB_APP_ID"), "ID of the GitHub App to authenticate as. Defaults to $GITHUB_APP_ID")
+	flags.Int64Var(&appInstallationId, "app-installation-id", envInt64("GITHUB_APP_INSTALLATION_ID"), "ID of the GitHub App installation. Defaults to $GITHUB_APP_INSTALLATION_ID")
+	flags.StringVar(&appPrivateKeyPath, "app-private-key", os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"), "Path of the GitHub App private key. Defaults to $GITHUB_APP_PRIVATE_KEY_PATH. The key can also be passed in $GITHUB_APP_PRIVATE_KEY")
+}
+
+func envInt64(key string) int64 {
+	value, _ := strconv.ParseInt(os.Getenv(key), 10, 64)
+	return value
+}
+
+// NewGithubService authenticates against the configured GitHub API as a GitHub App installation when an app id is set.
+// Otherwise it uses GITHUB_TOKEN, or the gh cli when it is not set.
+func NewGithubService() (github.IGithubService, error) {
+	options := serviceOptions
+	options.BaseURL = githubUrl
+
+	if appId != 0 {
+		credentials, err := appCredentials()
+		if err != nil {
+			return nil, err
+		}
+		return github.NewGithubAppService(credentials, options)
+	}
+
+	authToken, set := os.LookupEnv("GITHUB_TOKEN")
+	if !set {
+		var err error
+		authToken, err = getTokenFromGhCli(options.BaseURL)
+		if err != nil {
+			return nil, errors.New("GITHUB_TOKEN environment variable not set and unable to authenticate with gh cli")
+		}
+	}
+
+	return github.NewGithubService(authToken, options)
+}
+
+func appCredentials() (github.AppCredentials, error) {
+	if appInstallationId == 0 {
+		return github.AppCredentials{}, errors.New("a GitHub App installation id is required to authenticate as a GitHub App")
+	}
+
+	privateKey := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if appPrivateKeyPath != "" {
+		var err error
+		privateKey, err = os.ReadFile(appPrivateKeyPath)
+		if err != nil {
+			return github.AppCredentials{}, fmt.Errorf("unable to read the GitHub App private key: %w", err)
+		}
+	}
+	if len(privateKey) == 0 {
+		return github.AppCredentials{}, errors.New("a GitHub App private key is required to authenticate as a GitHub App")
+	}
+
+	return github.AppCredentials{
+		AppId:          appId,
+		InstallationId: appInstallationId,
+		PrivateKey:     privateKey,
+	}, nil
+}
+
+func getTokenFromGhCli(baseURL string) (string, error) {
+	cmd, set := os.LookupEnv("GH_PATH")
+	if !set {
+		cmd = "gh"
+	}
+
+	args := []string{"auth", "token"}
+	// The gh cli keeps one token per host
+	if u, err := url.Parse(baseURL); err == nil && u.Host != "" && !github.IsDotCom(baseURL) {
+		args = append(args, "--hostname", u.Host)
+	}
+
+	out, err := exec.Command(cmd, args...).Output()
+	if err != nil {
+		return "", errors.New("unable to authenticate with gh cli")
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
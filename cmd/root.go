@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"gh_foundations/cmd/alerts"
 	"gh_foundations/cmd/check"
 	"gh_foundations/cmd/gen"
 	import_cmd "gh_foundations/cmd/import"
 	"gh_foundations/cmd/list"
+	"gh_foundations/cmd/snapshot"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -45,4 +47,6 @@ func init() {
 	rootCmd.AddCommand(gen.GenCmd)
 	rootCmd.AddCommand(check.CheckCmd)
 	rootCmd.AddCommand(list.ListCmd)
+	rootCmd.AddCommand(snapshot.SnapshotCmd)
+	rootCmd.AddCommand(alerts.AlertsCmd)
 }
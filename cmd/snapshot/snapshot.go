@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"errors"
+	"gh_foundations/cmd/github_service"
+	"gh_foundations/internal/pkg/types/github"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+var output string
+
+var SnapshotCmd = &cobra.Command{
+	Use:   "snapshot <org-slug>...",
+	Short: "Save the configuration of GitHub organizations to a file.",
+	Long: `Fetch the organizations, their repositories, rulesets, custom repository roles, teams, members, Actions settings, workflows, community health files and open security alerts and save them to a versioned JSON file.
+Pass the file to "check --from-snapshot" to run the checks again without network access.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 {
+			return errors.New("requires at least one GitHub organization slug")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		gs, err := github_service.NewGithubService()
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		snapshot, err := github.CaptureSnapshot(gs, args, github.RepositoryListOptions{Type: "all", BranchProtection: true, ActionsSettings: true, Contents: true, Alerts: true})
+		if err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+
+		var out io.Writer = cmd.OutOrStdout()
+		if output != "-" {
+			file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		if err := snapshot.Write(out); err != nil {
+			cmd.PrintErrln(err)
+			os.Exit(1)
+		}
+		for _, o := range snapshot.Organizations {
+			if o.TeamsError != "" {
+				cmd.PrintErrf("Unable to get teams of %q: %s\n", o.Organization.GetLogin(), o.TeamsError)
+			}
+			if o.MembersError != "" {
+				cmd.PrintErrf("Unable to get members of %q: %s\n", o.Organization.GetLogin(), o.MembersError)
+			}
+		}
+		if output != "-" {
+			cmd.PrintErrf("Wrote a snapshot of %d organizations to %s\n", len(snapshot.Organizations), output)
+		}
+	},
+}
+
+func init() {
+	SnapshotCmd.Flags().StringVarP(&output, "output", "o", "snapshot.json", "Path of the snapshot file, or - to write to stdout")
+	github_service.AddFlags(SnapshotCmd.Flags())
+}
@@ -114,6 +114,7 @@ func FindManagedRepos(reposDir string) (status.OrgSet, error) {
 
 		var repos status.OrgProjectSet
 		repos.RepositorySets = make(map[string]githubfoundations.RepositorySetInput)
+		repos.Paths = make(map[string]string)
 		orgSet.OrgProjectSets[org] = repos
 
 		for _, file := range files {
@@ -121,6 +122,8 @@ func FindManagedRepos(reposDir string) (status.OrgSet, error) {
 			// If the file name ends with `../repositories/terragrunt.hcl`,
 			// then it is a repository file
 			if strings.HasSuffix(file, "repositories/terragrunt.hcl") {
+				// The path as found in the projects directory, relative when the directory was given as a relative path
+				configPath := file
 
 				// Strip the trailing / from the reposDir
 				replaceDir := strings.TrimSuffix(reposDir, "/")
@@ -165,6 +168,7 @@ func FindManagedRepos(reposDir string) (status.OrgSet, error) {
 
 				// Add the repoSet to the orgSet
 				orgSet.OrgProjectSets[org].RepositorySets[project] = repoSet
+				orgSet.OrgProjectSets[org].Paths[project] = configPath
 			}
 		}
 	}
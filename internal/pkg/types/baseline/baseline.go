@@ -0,0 +1,179 @@
+package baseline
+
+import (
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+var fs = afero.NewOsFs()
+
+const dateFormat = "2006-01-02"
+
+// A Baseline lists the violations that have been accepted as exceptions.
+type Baseline struct {
+	Suppressions []Suppression `yaml:"suppressions"`
+}
+
+// A Suppression accepts the violation of a rule by a single entity until its expiry date.
+// Suppressions without an expiry date never expire, and suppressions without an organization
+// apply to the entity in every organization.
+type Suppression struct {
+	Organization  string `yaml:"organization,omitempty"`
+	EntityType    string `yaml:"entity_type"`
+	EntityId      string `yaml:"entity_id"`
+	Rule          string `yaml:"rule"`
+	Justification string `yaml:"justification"`
+	Expires       string `yaml:"expires,omitempty"`
+}
+
+func LoadBaseline(path string) (*Baseline, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read baseline file %q: %w", path, err)
+	}
+
+	var baseline Baseline
+	if err := yaml.UnmarshalStrict(data, &baseline); err != nil {
+		return nil, fmt.Errorf("unable to parse baseline file %q: %w", path, err)
+	}
+	if err := baseline.Validate(); err != nil {
+		return nil, err
+	}
+	return &baseline, nil
+}
+
+func (b *Baseline) Validate() error {
+	var errs error
+	for i, s := range b.Suppressions {
+		if s.EntityType == "" || s.EntityId == "" || s.Rule == "" {
+			errs = errors.Join(errs, fmt.Errorf("suppression %d: entity_type, entity_id and rule are required", i))
+		}
+		if s.Justification == "" {
+			errs = errors.Join(errs, fmt.Errorf("suppression %d: a justification is required", i))
+		}
+		if _, err := s.ExpiryDate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("suppression %d: %w", i, err))
+		}
+	}
+	return errs
+}
+
+// ExpiryDate returns the end of the day the suppression expires on, or the zero time if it never expires
+func (s *Suppression) ExpiryDate() (time.Time, error) {
+	if s.Expires == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(dateFormat, s.Expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry date %q. Expected the YYYY-MM-DD format", s.Expires)
+	}
+	return date.AddDate(0, 0, 1), nil
+}
+
+func (s *Suppression) Expired(now time.Time) bool {
+	expiry, _ := s.ExpiryDate()
+	return !expiry.IsZero() && !now.Before(expiry)
+}
+
+// matches reports whether the suppression covers the violation key. A suppression of a rule id covers every
+// violation the rule reports under a sub key, e.g. rulesets covers rulesets.pull_request.
+func (s *Suppression) matches(key string) bool {
+	return key == s.Rule || strings.HasPrefix(key, s.Rule+".")
+}
+
+func (b *Baseline) find(report types.CheckReport, key string) *Suppression {
+	for i := range b.Suppressions {
+		s := &b.Suppressions[i]
+		if s.Organization != "" && s.Organization != report.Organization {
+			continue
+		}
+		if s.EntityType == report.EntityType && s.EntityId == report.EntityId && s.matches(key) {
+			return s
+		}
+	}
+	return nil
+}
+
+// Apply moves the violations matching an active suppression to the suppressed violations of their check.
+// A check whose violations are all suppressed gets the Suppressed result. Violations matching an expired
+// suppression are left as failures and their message notes the expiry.
+func (b *Baseline) Apply(reports []types.CheckReport, now time.Time) {
+	for _, report := range reports {
+		for i := range report.Errors {
+			checkError := &report.Errors[i]
+			for key, message := range checkError.Violations {
+				s := b.find(report, key)
+				if s == nil {
+					continue
+				}
+				if s.Expired(now) {
+					checkError.Violations[key] = fmt.Sprintf("%s (suppression expired on %s)", message, s.Expires)
+					continue
+				}
+				if checkError.Suppressed == nil {
+					checkError.Suppressed = make(map[string]string)
+				}
+				checkError.Suppressed[key] = s.Justification
+				delete(checkError.Violations, key)
+			}
+
+			if len(checkError.Violations) == 0 && len(checkError.Suppressed) > 0 && report.Results[checkError.Check] == types.Failed {
+				report.Results[checkError.Check] = types.Suppressed
+			}
+		}
+	}
+}
+
+// FromReports creates a baseline suppressing every violation found in the reports
+func FromReports(reports []types.CheckReport, justification string, expires string) *Baseline {
+	baseline := &Baseline{Suppressions: []Suppression{}}
+	for _, report := range reports {
+		for _, checkError := range report.Errors {
+			for key := range checkError.Violations {
+				if baseline.find(report, key) != nil {
+					continue
+				}
+				baseline.Suppressions = append(baseline.Suppressions, Suppression{
+					Organization:  report.Organization,
+					EntityType:    report.EntityType,
+					EntityId:      report.EntityId,
+					Rule:          key,
+					Justification: justification,
+					Expires:       expires,
+				})
+			}
+		}
+	}
+
+	sort.Slice(baseline.Suppressions, func(i, j int) bool {
+		a, b := baseline.Suppressions[i], baseline.Suppressions[j]
+		if a.Organization != b.Organization {
+			return a.Organization < b.Organization
+		}
+		if a.EntityType != b.EntityType {
+			return a.EntityType < b.EntityType
+		}
+		if a.EntityId != b.EntityId {
+			return a.EntityId < b.EntityId
+		}
+		return a.Rule < b.Rule
+	})
+	return baseline
+}
+
+func (b *Baseline) Write(w io.Writer) error {
+	data, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
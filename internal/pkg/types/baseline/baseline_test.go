@@ -0,0 +1,152 @@
+package baseline
+
+import (
+	"bytes"
+	"gh_foundations/internal/pkg/types"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBaseline = `
+suppressions:
+  - entity_type: github_repository
+    entity_id: docs
+    rule: rulesets
+    justification: public docs repository intentionally has no ruleset
+    expires: 2026-06-30
+  - entity_type: github_repository
+    entity_id: docs
+    rule: delete_branch_on_merge
+    justification: branches are kept for release notes
+`
+
+func newTestReports() []types.CheckReport {
+	return []types.CheckReport{
+		{
+			EntityType: "github_repository",
+			EntityId:   "docs",
+			Results:    map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Failed},
+			Errors: []types.CheckError{
+				{
+					Check: types.GoCGuardrails,
+					Violations: map[string]string{
+						"rulesets":               "expected a ruleset",
+						"delete_branch_on_merge": "delete_branch_on_merge is not enabled. Expected it to be enabled",
+					},
+				},
+			},
+		},
+	}
+}
+
+func loadTestBaseline(t *testing.T) *Baseline {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/baseline.yaml", []byte(testBaseline), 0644)
+	b, err := LoadBaseline("/baseline.yaml")
+	assert.NoError(t, err)
+	return b
+}
+
+func TestLoadBaseline(t *testing.T) {
+	b := loadTestBaseline(t)
+
+	assert.Len(t, b.Suppressions, 2)
+	assert.Equal(t, "2026-06-30", b.Suppressions[0].Expires)
+}
+
+func TestLoadBaseline_InvalidExpiry(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/baseline.yaml", []byte(`
+suppressions:
+  - entity_type: github_repository
+    entity_id: docs
+    rule: rulesets
+    justification: accepted
+    expires: next week
+`), 0644)
+
+	_, err := LoadBaseline("/baseline.yaml")
+
+	assert.ErrorContains(t, err, "invalid expiry date")
+}
+
+func TestBaseline_ApplyActive(t *testing.T) {
+	b := loadTestBaseline(t)
+	reports := newTestReports()
+
+	b.Apply(reports, time.Date(2026, 6, 30, 12, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, types.Suppressed, reports[0].Results[types.GoCGuardrails])
+	assert.Empty(t, reports[0].Errors[0].Violations)
+	assert.Equal(t, "branches are kept for release notes", reports[0].Errors[0].Suppressed["delete_branch_on_merge"])
+}
+
+func TestBaseline_ApplyExpired(t *testing.T) {
+	b := loadTestBaseline(t)
+	reports := newTestReports()
+
+	b.Apply(reports, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, types.Failed, reports[0].Results[types.GoCGuardrails])
+	assert.Equal(t, "expected a ruleset (suppression expired on 2026-06-30)", reports[0].Errors[0].Violations["rulesets"])
+	assert.Contains(t, reports[0].Errors[0].Suppressed, "delete_branch_on_merge")
+}
+
+func TestFromReports(t *testing.T) {
+	b := FromReports(newTestReports(), "accepted", "2026-12-31")
+
+	assert.Len(t, b.Suppressions, 2)
+	assert.Equal(t, "delete_branch_on_merge", b.Suppressions[0].Rule)
+	assert.Equal(t, "rulesets", b.Suppressions[1].Rule)
+
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, b.Write(buffer))
+	assert.Contains(t, buffer.String(), "expires: \"2026-12-31\"")
+}
+
+func TestBaseline_ApplyOtherOrganization(t *testing.T) {
+	b := &Baseline{Suppressions: []Suppression{
+		{Organization: "other", EntityType: "github_repository", EntityId: "docs", Rule: "rulesets", Justification: "accepted"},
+	}}
+	reports := newTestReports()
+	reports[0].Organization = "org"
+
+	b.Apply(reports, time.Now())
+
+	assert.Contains(t, reports[0].Errors[0].Violations, "rulesets")
+	assert.Empty(t, reports[0].Errors[0].Suppressed)
+}
+
+func TestBaseline_ApplyRuleToSubKeys(t *testing.T) {
+	b := &Baseline{Suppressions: []Suppression{
+		{EntityType: "github_repository", EntityId: "api", Rule: "branch_protection", Justification: "protected by rulesets"},
+		{EntityType: "github_repository", EntityId: "api", Rule: "rulesets.pull_request", Justification: "accepted"},
+	}}
+	reports := []types.CheckReport{{
+		EntityType: "github_repository",
+		EntityId:   "api",
+		Results:    map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Failed},
+		Errors: []types.CheckError{{
+			Check: types.GoCGuardrails,
+			Violations: map[string]string{
+				"branch_protection.main.protected":            "branch main: branch protection is not enabled",
+				"branch_protection.release.enforce_admins":    "branch release: enforce_admins is not enabled",
+				"branch_protection_extra":                     "not covered by the branch_protection suppression",
+				"rulesets.pull_request":                       "pull_request rule is not enforced",
+				"rulesets.pull_request.dismiss_stale_reviews": "dismiss_stale_reviews_on_push is false",
+				"rulesets.required_signatures":                "required_signatures rule is not enforced",
+			},
+		}},
+	}}
+
+	b.Apply(reports, time.Now())
+
+	assert.Equal(t, map[string]string{
+		"branch_protection_extra":      "not covered by the branch_protection suppression",
+		"rulesets.required_signatures": "required_signatures rule is not enforced",
+	}, reports[0].Errors[0].Violations)
+	assert.Len(t, reports[0].Errors[0].Suppressed, 4)
+}
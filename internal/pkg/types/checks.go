@@ -2,15 +2,17 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
 type CheckResult uint16
 
 const (
-	Failed        CheckResult = iota
-	Passed                    = iota
-	Errored                   = iota
-	NotApplicable             = iota
+	Failed CheckResult = iota
+	Passed
+	Errored
+	NotApplicable
+	Suppressed
 )
 
 func (c CheckResult) String() string {
@@ -23,6 +25,8 @@ func (c CheckResult) String() string {
 		return "Errored"
 	case NotApplicable:
 		return "Not Applicable"
+	case Suppressed:
+		return "Suppressed"
 	default:
 		return "Unknown"
 	}
@@ -32,26 +36,66 @@ func (c CheckResult) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.String())
 }
 
+// UnmarshalJSON reads the results written by MarshalJSON so that previous reports can be loaded again
+func (c *CheckResult) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for _, result := range []CheckResult{Failed, Passed, Errored, NotApplicable, Suppressed} {
+		if result.String() == name {
+			*c = result
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown check result %q", name)
+}
+
 type CheckError struct {
 	Err        error             `json:"-"`
 	Check      CheckType         `json:"check"`
 	Violations map[string]string `json:"violations"`
+	// Violations accepted through a baseline, mapped to the justification of their suppression
+	Suppressed map[string]string `json:"suppressed,omitempty"`
+	// Rules that could not be evaluated, mapped to the reason
+	Unevaluated map[string]string `json:"unevaluated,omitempty"`
+	// Benchmark controls of the violations, by violation
+	Controls map[string][]Control `json:"controls,omitempty"`
 }
 
 type CheckType string
 
 const (
 	GoCGuardrails = "GoCGuardrails"
+	// Ad hoc profile built from the rules selected on the command line
+	CustomRules CheckType = "Custom"
+	// Classic branch protection of the default branch and of the branches protected in the HCL inputs
+	BranchProtection CheckType = "BranchProtection"
+	// Teams and members of the organizations
+	Membership CheckType = "Membership"
+	// GitHub Actions settings of the organizations and repositories
+	ActionsSecurity CheckType = "ActionsSecurity"
+	// Controls of the CIS Software Supply Chain Security Guide that can be evaluated through the API
+	CISSoftwareSupplyChain CheckType = "CISSoftwareSupplyChain"
+	// OpenSSF Scorecard checks that can be evaluated through the API
+	OpenSSFScorecard CheckType = "OpenSSFScorecard"
+	// Age of the open Dependabot, code scanning and secret scanning alerts of the repositories
+	SecurityAlerts CheckType = "SecurityAlerts"
+	// README, LICENSE, SECURITY.md and CODEOWNERS files required in the repositories of each visibility
+	RepositoryHygiene CheckType = "RepositoryHygiene"
 )
 
 type CheckReport struct {
 	EntityType 	string						`json:"entity_type"`
 	EntityId   	string						`json:"entity_id"`
+	Organization	string					`json:"organization,omitempty"`
 	Timestamp	string						`json:"rfc3339_timestamp"`
 	Results    	map[CheckType]CheckResult	`json:"results"`
 	Errors     	[]CheckError				`json:"errors"`
+	// Rules of the check types skipped with --disable-rules
+	Disabled	[]string					`json:"disabled,omitempty"`
 }
 
 type ICheckable interface {
-	Check(checkTypes []CheckType) CheckReport
+	Check(registry *CheckRegistry, checkTypes []CheckType) CheckReport
 }
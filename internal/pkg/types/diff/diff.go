@@ -0,0 +1,287 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/afero"
+)
+
+var fs = afero.NewOsFs()
+
+// Formats supported by Diff.Write
+var Formats = []string{"table", "markdown", "json"}
+
+type Status string
+
+const (
+	// The violation was not reported by the previous run
+	New Status = "new"
+	// The violation was reported by the previous run only
+	Resolved Status = "resolved"
+	// The violation was reported by both runs
+	Persisting Status = "persisting"
+	// The violation was reported by the previous run, and the current run did not check the entity against its rule
+	NotEvaluated Status = "not_evaluated"
+	// The violation was reported by the previous run, and the current run suppressed it through a baseline
+	Suppressed Status = "suppressed"
+)
+
+// A Change is the status of the violation of an entity between two runs
+type Change struct {
+	Status       Status `json:"status"`
+	Organization string `json:"organization,omitempty"`
+	EntityType   string `json:"entity_type"`
+	EntityId     string `json:"entity_id"`
+	Violation    string `json:"violation"`
+	Message      string `json:"message"`
+}
+
+// A Diff lists the violations of two runs classified as new, resolved, persisting, not evaluated or suppressed
+type Diff struct {
+	Changes []Change `json:"changes"`
+}
+
+// Identifies a violation across runs
+type violationKey struct {
+	organization string
+	entityType   string
+	entityId     string
+	violation    string
+}
+
+func (k violationKey) less(other violationKey) bool {
+	if k.organization != other.organization {
+		return k.organization < other.organization
+	}
+	if k.entityType != other.entityType {
+		return k.entityType < other.entityType
+	}
+	if k.entityId != other.entityId {
+		return k.entityId < other.entityId
+	}
+	return k.violation < other.violation
+}
+
+// A violation of a run with the check types that reported it
+type violation struct {
+	message string
+	checks  []types.CheckType
+}
+
+// violations indexes the violations of the reports. Violations reported by several profiles are only
+// recorded once.
+func violations(reports []types.CheckReport) map[violationKey]*violation {
+	return index(reports, func(checkError types.CheckError) map[string]string { return checkError.Violations })
+}
+
+// suppressed indexes the violations of the reports suppressed through a baseline, with their justification
+func suppressed(reports []types.CheckReport) map[violationKey]*violation {
+	return index(reports, func(checkError types.CheckError) map[string]string { return checkError.Suppressed })
+}
+
+func index(reports []types.CheckReport, messages func(types.CheckError) map[string]string) map[violationKey]*violation {
+	found := make(map[violationKey]*violation)
+	for _, report := range reports {
+		for _, checkError := range report.Errors {
+			for key, message := range messages(checkError) {
+				k := violationKey{report.Organization, report.EntityType, report.EntityId, key}
+				if _, ok := found[k]; !ok {
+					found[k] = &violation{message: message}
+				}
+				found[k].checks = append(found[k].checks, checkError.Check)
+			}
+		}
+	}
+	return found
+}
+
+// Identifies the run of a check type against an entity
+type checkKey struct {
+	organization string
+	entityType   string
+	entityId     string
+	check        types.CheckType
+}
+
+// evaluatedChecks indexes the check types run against every entity of the reports, with the rules they could not
+// evaluate. Disabled rules were not evaluated by any of the check types.
+func evaluatedChecks(reports []types.CheckReport) map[checkKey]map[string]string {
+	checks := make(map[checkKey]map[string]string)
+	for _, report := range reports {
+		for check := range report.Results {
+			unevaluated := make(map[string]string)
+			for _, rule := range report.Disabled {
+				unevaluated[rule] = "disabled"
+			}
+			checks[checkKey{report.Organization, report.EntityType, report.EntityId, check}] = unevaluated
+		}
+		for _, checkError := range report.Errors {
+			unevaluated := checks[checkKey{report.Organization, report.EntityType, report.EntityId, checkError.Check}]
+			if unevaluated == nil {
+				unevaluated = make(map[string]string)
+			}
+			for rule, reason := range checkError.Unevaluated {
+				unevaluated[rule] = reason
+			}
+			checks[checkKey{report.Organization, report.EntityType, report.EntityId, checkError.Check}] = unevaluated
+		}
+	}
+	return checks
+}
+
+// evaluated reports whether one of the check types that found the violation evaluated its rule against the entity again
+func evaluated(checks map[checkKey]map[string]string, key violationKey, v *violation) bool {
+	for _, check := range v.checks {
+		unevaluated, ok := checks[checkKey{key.organization, key.entityType, key.entityId, check}]
+		if !ok {
+			continue
+		}
+		ruleEvaluated := true
+		for rule := range unevaluated {
+			if key.violation == rule || strings.HasPrefix(key.violation, rule+".") {
+				ruleEvaluated = false
+			}
+		}
+		if ruleEvaluated {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare classifies the violations of the previous and current reports by entity and violation key.
+// A violation missing from the current reports is suppressed when the current run suppressed it through a baseline.
+// Otherwise it is only resolved when the current run evaluated its rule against the entity in one of the check types
+// that reported it, and is not evaluated when the rule was disabled or could not be evaluated.
+// Violations only suppressed by the current run are left out since they were accepted.
+func Compare(previous []types.CheckReport, current []types.CheckReport) Diff {
+	before := violations(previous)
+	after := violations(current)
+	accepted := suppressed(current)
+	checks := evaluatedChecks(current)
+
+	keys := make([]violationKey, 0, len(before)+len(after))
+	for key := range after {
+		keys = append(keys, key)
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+
+	diff := Diff{Changes: make([]Change, 0, len(keys))}
+	for _, key := range keys {
+		change := Change{
+			Organization: key.organization,
+			EntityType:   key.entityType,
+			EntityId:     key.entityId,
+			Violation:    key.violation,
+		}
+		v, inCurrent := after[key]
+		old, inPrevious := before[key]
+		switch {
+		case inCurrent && inPrevious:
+			change.Status = Persisting
+			change.Message = v.message
+		case inCurrent:
+			change.Status = New
+			change.Message = v.message
+		case accepted[key] != nil:
+			change.Status = Suppressed
+			change.Message = old.message
+		case evaluated(checks, key, old):
+			change.Status = Resolved
+			change.Message = old.message
+		default:
+			change.Status = NotEvaluated
+			change.Message = old.message
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	return diff
+}
+
+// Count returns the number of changes with the given status
+func (d Diff) Count(status Status) int {
+	count := 0
+	for _, c := range d.Changes {
+		if c.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+func (d Diff) String() string {
+	return fmt.Sprintf("%d new, %d resolved, %d persisting, %d not evaluated and %d suppressed violations", d.Count(New), d.Count(Resolved), d.Count(Persisting), d.Count(NotEvaluated), d.Count(Suppressed))
+}
+
+// LoadReports reads a report written by check in the json format
+func LoadReports(path string) ([]types.CheckReport, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read report %q: %w", path, err)
+	}
+	var reports []types.CheckReport
+	if err := json.Unmarshal(data, &reports); err != nil {
+		return nil, fmt.Errorf("unable to parse report %q. Only json reports can be compared: %w", path, err)
+	}
+	return reports, nil
+}
+
+// Write serializes the diff in one of the Formats
+func (d Diff) Write(w io.Writer, format string) error {
+	switch format {
+	case "table":
+		return d.writeTable(w)
+	case "markdown":
+		return d.writeMarkdown(w)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(d)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func (d Diff) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tORGANIZATION\tENTITY TYPE\tENTITY\tVIOLATION\tMESSAGE")
+	for _, c := range d.Changes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Status, c.Organization, c.EntityType, c.EntityId, c.Violation, strings.ReplaceAll(c.Message, "\n", " "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, d)
+	return err
+}
+
+func (d Diff) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("## Check diff\n\n")
+	fmt.Fprintf(&b, "%s.\n", d)
+	if len(d.Changes) > 0 {
+		b.WriteString("\n| Status | Organization | Entity | Type | Violation | Message |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		for _, c := range d.Changes {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", c.Status, escapeMarkdown(c.Organization), escapeMarkdown(c.EntityId), c.EntityType, c.Violation, escapeMarkdown(c.Message))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
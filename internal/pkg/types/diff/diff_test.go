@@ -0,0 +1,162 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"gh_foundations/internal/pkg/types"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReport(entityId string, violations map[string]string) types.CheckReport {
+	result := types.Passed
+	if len(violations) > 0 {
+		result = types.Failed
+	}
+	return types.CheckReport{
+		EntityType:   "github_repository",
+		EntityId:     entityId,
+		Organization: "acme",
+		Results:      map[types.CheckType]types.CheckResult{types.GoCGuardrails: result},
+		Errors:       []types.CheckError{{Check: types.GoCGuardrails, Violations: violations}},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	previous := []types.CheckReport{
+		newTestReport("api", map[string]string{
+			"secret_scanning":        "secret_scanning is disabled. Expected it to be enabled",
+			"delete_branch_on_merge": "delete_branch_on_merge is not enabled. Expected it to be enabled",
+		}),
+	}
+	current := []types.CheckReport{
+		newTestReport("api", map[string]string{
+			"secret_scanning": "secret_scanning is disabled. Expected it to be enabled",
+		}),
+		newTestReport("web", map[string]string{
+			"rulesets.pull_request": "pull_request rule is missing",
+		}),
+	}
+
+	d := Compare(previous, current)
+
+	assert.Equal(t, []Change{
+		{Status: Resolved, Organization: "acme", EntityType: "github_repository", EntityId: "api", Violation: "delete_branch_on_merge", Message: "delete_branch_on_merge is not enabled. Expected it to be enabled"},
+		{Status: Persisting, Organization: "acme", EntityType: "github_repository", EntityId: "api", Violation: "secret_scanning", Message: "secret_scanning is disabled. Expected it to be enabled"},
+		{Status: New, Organization: "acme", EntityType: "github_repository", EntityId: "web", Violation: "rulesets.pull_request", Message: "pull_request rule is missing"},
+	}, d.Changes)
+	assert.Equal(t, "1 new, 1 resolved, 1 persisting, 0 not evaluated and 0 suppressed violations", d.String())
+}
+
+func TestCompare_Suppressed(t *testing.T) {
+	previous := []types.CheckReport{
+		newTestReport("api", map[string]string{"delete_branch_on_merge": "delete_branch_on_merge is not enabled. Expected it to be enabled"}),
+	}
+	current := []types.CheckReport{newTestReport("api", nil)}
+	current[0].Results[types.GoCGuardrails] = types.Suppressed
+	current[0].Errors[0].Suppressed = map[string]string{
+		"delete_branch_on_merge": "accepted",
+		// Suppressed since the first run
+		"secret_scanning": "accepted",
+	}
+
+	d := Compare(previous, current)
+
+	assert.Equal(t, []Change{
+		{Status: Suppressed, Organization: "acme", EntityType: "github_repository", EntityId: "api", Violation: "delete_branch_on_merge", Message: "delete_branch_on_merge is not enabled. Expected it to be enabled"},
+	}, d.Changes)
+	assert.Equal(t, 0, d.Count(Resolved))
+}
+
+func TestCompare_DisabledRule(t *testing.T) {
+	previous := []types.CheckReport{
+		newTestReport("api", map[string]string{
+			"secret_scanning":       "disabled",
+			"rulesets.pull_request": "pull_request rule is missing",
+		}),
+	}
+	current := []types.CheckReport{newTestReport("api", nil)}
+	current[0].Disabled = []string{"rulesets"}
+
+	d := Compare(previous, current)
+
+	assert.Equal(t, []Change{
+		{Status: NotEvaluated, Organization: "acme", EntityType: "github_repository", EntityId: "api", Violation: "rulesets.pull_request", Message: "pull_request rule is missing"},
+		{Status: Resolved, Organization: "acme", EntityType: "github_repository", EntityId: "api", Violation: "secret_scanning", Message: "disabled"},
+	}, d.Changes)
+}
+
+func TestCompare_NotEvaluated(t *testing.T) {
+	previous := []types.CheckReport{
+		newTestReport("api", map[string]string{"secret_scanning": "disabled"}),
+		newTestReport("web", map[string]string{"rulesets.pull_request": "pull_request rule is missing"}),
+		newTestReport("docs", map[string]string{"secret_scanning": "disabled"}),
+	}
+	current := []types.CheckReport{
+		// Checked against another profile only
+		newTestReport("api", nil),
+		// The rulesets could not be read
+		newTestReport("web", nil),
+	}
+	current[0].Results = map[types.CheckType]types.CheckResult{types.OpenSSFScorecard: types.Passed}
+	current[0].Errors[0].Check = types.OpenSSFScorecard
+	current[1].Results[types.GoCGuardrails] = types.Errored
+	current[1].Errors[0].Unevaluated = map[string]string{"rulesets": "unable to read the rules of the default branch"}
+
+	d := Compare(previous, current)
+
+	assert.Equal(t, 3, d.Count(NotEvaluated))
+	assert.Equal(t, 0, d.Count(Resolved))
+}
+
+func TestCompare_DuplicateProfiles(t *testing.T) {
+	report := newTestReport("api", map[string]string{"secret_scanning": "disabled"})
+	report.Errors = append(report.Errors, types.CheckError{Check: types.OpenSSFScorecard, Violations: map[string]string{"secret_scanning": "disabled"}})
+
+	d := Compare(nil, []types.CheckReport{report})
+
+	assert.Len(t, d.Changes, 1)
+	assert.Equal(t, 1, d.Count(New))
+}
+
+func TestLoadReports(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	reports := []types.CheckReport{newTestReport("api", map[string]string{"secret_scanning": "disabled"})}
+	data, err := json.Marshal(reports)
+	assert.NoError(t, err)
+	afero.WriteFile(fs, "/report.json", data, 0644)
+
+	loaded, err := LoadReports("/report.json")
+
+	assert.NoError(t, err)
+	assert.Len(t, loaded, 1)
+	assert.Equal(t, types.Failed, loaded[0].Results[types.GoCGuardrails])
+	assert.Equal(t, "disabled", loaded[0].Errors[0].Violations["secret_scanning"])
+}
+
+func TestLoadReports_NotJson(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/report.md", []byte("## Check results"), 0644)
+
+	_, err := LoadReports("/report.md")
+
+	assert.ErrorContains(t, err, "Only json reports can be compared")
+}
+
+func TestDiff_Write(t *testing.T) {
+	d := Compare(nil, []types.CheckReport{newTestReport("api", map[string]string{"secret_scanning": "is | disabled"})})
+
+	var markdown bytes.Buffer
+	assert.NoError(t, d.Write(&markdown, "markdown"))
+	assert.Contains(t, markdown.String(), "| new | acme | api | github_repository | secret_scanning | is \\| disabled |")
+
+	var out bytes.Buffer
+	assert.NoError(t, d.Write(&out, "json"))
+	var decoded Diff
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, d, decoded)
+
+	assert.Error(t, d.Write(&out, "sarif"))
+}
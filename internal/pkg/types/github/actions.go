@@ -0,0 +1,404 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// Keys of the Actions settings, used to record the errors reading them
+const (
+	actionsPermissionsSetting      = "permissions"
+	workflowPermissionsSetting     = "workflow_permissions"
+	forkPullRequestApprovalSetting = "fork_pull_request_approval"
+	runnersSetting                 = "runners"
+)
+
+// Fork pull request approval policies that require an approval before the workflows of outside contributors run.
+// The remaining policy, first_time_contributors_new_to_github, lets anyone with an established account run workflows.
+var compliantForkPullRequestApprovals = []string{"first_time_contributors", "all_external_contributors"}
+
+// OrganizationActions holds the GitHub Actions settings of an organization
+type OrganizationActions struct {
+	Permissions             *github.ActionsPermissions                    `json:"permissions,omitempty"`
+	WorkflowPermissions     *github.DefaultWorkflowPermissionOrganization `json:"workflow_permissions,omitempty"`
+	ForkPullRequestApproval string                                        `json:"fork_pull_request_approval,omitempty"`
+	RunnerGroups            []*github.RunnerGroup                         `json:"runner_groups,omitempty"`
+	// Errors reading a setting, by setting
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// RepositoryActions holds the GitHub Actions settings of a repository
+type RepositoryActions struct {
+	Permissions             *github.ActionsPermissionsRepository        `json:"permissions,omitempty"`
+	WorkflowPermissions     *github.DefaultWorkflowPermissionRepository `json:"workflow_permissions,omitempty"`
+	ForkPullRequestApproval string                                      `json:"fork_pull_request_approval,omitempty"`
+	// Names of the self-hosted runners registered to the repository. Only fetched for public repositories.
+	SelfHostedRunners []string `json:"self_hosted_runners,omitempty"`
+	// Errors reading a setting, by setting
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+func recordError(errs *map[string]string, setting string, err error) {
+	if *errs == nil {
+		*errs = make(map[string]string)
+	}
+	(*errs)[setting] = err.Error()
+}
+
+// partialEvaluation returns the violations found in the parts of an entity that could be read, joined with an
+// EvaluationError listing the parts that could not
+func partialEvaluation(what string, unreadable []string, violations types.Violations) error {
+	var errs error
+	if len(unreadable) > 0 {
+		sort.Strings(unreadable)
+		errs = types.EvaluationErrorf("unable to read %s: %s", what, strings.Join(unreadable, "; "))
+	}
+	if len(violations) > 0 {
+		errs = errors.Join(errs, violations)
+	}
+	return errs
+}
+
+// The fork pull request approval policy is not part of go-github yet
+type forkPullRequestApproval struct {
+	ApprovalPolicy string `json:"approval_policy"`
+}
+
+func (g *GithubService) getForkPullRequestApproval(path string) (string, error) {
+	ctx, cancelFn := g.requestContext()
+	defer cancelFn()
+	req, err := g.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return "", err
+	}
+	var approval forkPullRequestApproval
+	if _, err := g.client.Do(ctx, req, &approval); err != nil {
+		return "", err
+	}
+	return approval.ApprovalPolicy, nil
+}
+
+// getOrganizationActions fetches the Actions settings of the organization. Reading them requires an organization owner token,
+// so errors are kept per setting and reported by the rules that need them.
+func (g *GithubService) getOrganizationActions(org string) *OrganizationActions {
+	actions := &OrganizationActions{}
+
+	ctx, cancelFn := g.requestContext()
+	permissions, _, err := g.client.Actions.GetActionsPermissions(ctx, org)
+	cancelFn()
+	if err != nil {
+		recordError(&actions.Errors, actionsPermissionsSetting, err)
+	}
+	actions.Permissions = permissions
+
+	ctx, cancelFn = g.requestContext()
+	workflowPermissions, _, err := g.client.Actions.GetDefaultWorkflowPermissionsInOrganization(ctx, org)
+	cancelFn()
+	if err != nil {
+		recordError(&actions.Errors, workflowPermissionsSetting, err)
+	}
+	actions.WorkflowPermissions = workflowPermissions
+
+	approval, err := g.getForkPullRequestApproval(fmt.Sprintf("orgs/%v/actions/permissions/fork-pr-contributor-approval", org))
+	if err != nil {
+		recordError(&actions.Errors, forkPullRequestApprovalSetting, err)
+	}
+	actions.ForkPullRequestApproval = approval
+
+	groups, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.RunnerGroup, *github.Response, error) {
+		page, resp, err := g.client.Actions.ListOrganizationRunnerGroups(ctx, org, &github.ListOrgRunnerGroupOptions{ListOptions: opts})
+		if page == nil {
+			return nil, resp, err
+		}
+		return page.RunnerGroups, resp, err
+	})
+	if err != nil {
+		recordError(&actions.Errors, runnersSetting, err)
+	}
+	actions.RunnerGroups = groups
+
+	return actions
+}
+
+// getRepositoryActions fetches the Actions settings of the repository, keeping errors per setting
+func (g *GithubService) getRepositoryActions(owner string, repo *github.Repository) *RepositoryActions {
+	actions := &RepositoryActions{}
+
+	ctx, cancelFn := g.requestContext()
+	permissions, _, err := g.client.Repositories.GetActionsPermissions(ctx, owner, repo.GetName())
+	cancelFn()
+	if err != nil {
+		recordError(&actions.Errors, actionsPermissionsSetting, err)
+	}
+	actions.Permissions = permissions
+
+	ctx, cancelFn = g.requestContext()
+	workflowPermissions, _, err := g.client.Repositories.GetDefaultWorkflowPermissions(ctx, owner, repo.GetName())
+	cancelFn()
+	if err != nil {
+		recordError(&actions.Errors, workflowPermissionsSetting, err)
+	}
+	actions.WorkflowPermissions = workflowPermissions
+
+	// Outside contributors can only open pull requests from forks of public repositories
+	if repo.GetVisibility() != "public" {
+		return actions
+	}
+
+	approval, err := g.getForkPullRequestApproval(fmt.Sprintf("repos/%v/%v/actions/permissions/fork-pr-contributor-approval", owner, repo.GetName()))
+	if err != nil {
+		recordError(&actions.Errors, forkPullRequestApprovalSetting, err)
+	}
+	actions.ForkPullRequestApproval = approval
+
+	runners, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.Runner, *github.Response, error) {
+		page, resp, err := g.client.Actions.ListRunners(ctx, owner, repo.GetName(), &opts)
+		if page == nil {
+			return nil, resp, err
+		}
+		return page.Runners, resp, err
+	})
+	if err != nil {
+		recordError(&actions.Errors, runnersSetting, err)
+	}
+	for _, r := range runners {
+		actions.SelfHostedRunners = append(actions.SelfHostedRunners, r.GetName())
+	}
+
+	return actions
+}
+
+// settingError returns the error reading the setting, or an evaluation error when the Actions settings were not fetched at all
+func settingError(fetched bool, errs map[string]string, setting string) error {
+	if !fetched {
+		return types.EvaluationErrorf("the Actions settings were not fetched")
+	}
+	if message, ok := errs[setting]; ok {
+		return types.EvaluationErrorf("unable to read the Actions %s: %s", strings.ReplaceAll(setting, "_", " "), message)
+	}
+	return nil
+}
+
+func evaluateAllowedActions(allowedActions string) error {
+	if allowedActions == "all" {
+		return errors.New("allowed_actions is all. Expected it to be local_only or selected")
+	}
+	return nil
+}
+
+func evaluateDefaultWorkflowPermissions(permissions string) error {
+	if permissions != "read" {
+		return fmt.Errorf("default_workflow_permissions is %s. Expected it to be read", permissions)
+	}
+	return nil
+}
+
+func evaluateCanApprovePullRequests(canApprove bool) error {
+	if canApprove {
+		return errors.New("can_approve_pull_request_reviews is enabled. Expected it to be disabled")
+	}
+	return nil
+}
+
+func evaluateForkPullRequestApproval(approval string) error {
+	if !slices.Contains(compliantForkPullRequestApprovals, approval) {
+		return fmt.Errorf("fork pull request approval policy is %s. Expected it to be one of %s", approval, strings.Join(compliantForkPullRequestApprovals, ", "))
+	}
+	return nil
+}
+
+var organizationActionsRules = []types.Rule{
+	{
+		Id:          "actions_allowed_actions",
+		Description: "Workflows of the organization can only use local or selected actions",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			if err := settingError(org.actions != nil, org.actions.errors(), actionsPermissionsSetting); err != nil {
+				return err
+			}
+			if org.actions.Permissions.GetEnabledRepositories() == "none" {
+				return nil
+			}
+			return evaluateAllowedActions(org.actions.Permissions.GetAllowedActions())
+		}),
+	},
+	{
+		Id:          "actions_default_workflow_permissions",
+		Description: "The GITHUB_TOKEN of the organization's workflows is read only by default",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			if err := settingError(org.actions != nil, org.actions.errors(), workflowPermissionsSetting); err != nil {
+				return err
+			}
+			return evaluateDefaultWorkflowPermissions(org.actions.WorkflowPermissions.GetDefaultWorkflowPermissions())
+		}),
+	},
+	{
+		Id:          "actions_can_approve_pull_requests",
+		Description: "Workflows of the organization cannot approve pull requests",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			if err := settingError(org.actions != nil, org.actions.errors(), workflowPermissionsSetting); err != nil {
+				return err
+			}
+			return evaluateCanApprovePullRequests(org.actions.WorkflowPermissions.GetCanApprovePullRequestReviews())
+		}),
+	},
+	{
+		Id:          "actions_fork_pull_request_approval",
+		Description: "Workflows of pull requests from outside contributors need an approval to run",
+		Severity:    types.Medium,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			if err := settingError(org.actions != nil, org.actions.errors(), forkPullRequestApprovalSetting); err != nil {
+				return err
+			}
+			return evaluateForkPullRequestApproval(org.actions.ForkPullRequestApproval)
+		}),
+	},
+	{
+		Id:          "actions_runner_groups_public_repositories",
+		Description: "Self-hosted runner groups cannot be used by public repositories",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			if err := settingError(org.actions != nil, org.actions.errors(), runnersSetting); err != nil {
+				return err
+			}
+			var public []string
+			for _, group := range org.actions.RunnerGroups {
+				if group.GetAllowsPublicRepositories() {
+					public = append(public, group.GetName())
+				}
+			}
+			if len(public) > 0 {
+				return fmt.Errorf("runner groups %s allow public repositories. Expected self-hosted runners to be unavailable to public repositories", strings.Join(public, ", "))
+			}
+			return nil
+		}),
+	},
+}
+
+var repositoryActionsRules = []types.Rule{
+	{
+		Id:          "repository_actions_allowed_actions",
+		Description: "Workflows of the repository can only use local or selected actions",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if err := settingError(repo.actions != nil, repo.actions.errors(), actionsPermissionsSetting); err != nil {
+				return err
+			}
+			if !repo.actions.Permissions.GetEnabled() {
+				return nil
+			}
+			return evaluateAllowedActions(repo.actions.Permissions.GetAllowedActions())
+		}),
+	},
+	{
+		Id:          "repository_actions_default_workflow_permissions",
+		Description: "The GITHUB_TOKEN of the repository's workflows is read only by default",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if err := settingError(repo.actions != nil, repo.actions.errors(), workflowPermissionsSetting); err != nil {
+				return err
+			}
+			return evaluateDefaultWorkflowPermissions(repo.actions.WorkflowPermissions.GetDefaultWorkflowPermissions())
+		}),
+	},
+	{
+		Id:          "repository_actions_can_approve_pull_requests",
+		Description: "Workflows of the repository cannot approve pull requests",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if err := settingError(repo.actions != nil, repo.actions.errors(), workflowPermissionsSetting); err != nil {
+				return err
+			}
+			return evaluateCanApprovePullRequests(repo.actions.WorkflowPermissions.GetCanApprovePullRequestReviews())
+		}),
+	},
+	{
+		Id:          "repository_actions_fork_pull_request_approval",
+		Description: "Workflows of pull requests from outside contributors to public repositories need an approval to run",
+		Severity:    types.Medium,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if repo.GetVisibility() != "public" {
+				return nil
+			}
+			if err := settingError(repo.actions != nil, repo.actions.errors(), forkPullRequestApprovalSetting); err != nil {
+				return err
+			}
+			return evaluateForkPullRequestApproval(repo.actions.ForkPullRequestApproval)
+		}),
+	},
+	{
+		Id:          "repository_self_hosted_runners",
+		Description: "Public repositories have no self-hosted runners",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if repo.GetVisibility() != "public" {
+				return nil
+			}
+			if err := settingError(repo.actions != nil, repo.actions.errors(), runnersSetting); err != nil {
+				return err
+			}
+			if len(repo.actions.SelfHostedRunners) > 0 {
+				return fmt.Errorf("public repository has self-hosted runners %s. Expected it to have none", strings.Join(repo.actions.SelfHostedRunners, ", "))
+			}
+			return nil
+		}),
+	},
+}
+
+func (a *OrganizationActions) errors() map[string]string {
+	if a == nil {
+		return nil
+	}
+	return a.Errors
+}
+
+func (a *RepositoryActions) errors() map[string]string {
+	if a == nil {
+		return nil
+	}
+	return a.Errors
+}
+
+// NeedsOrganizationActions reports whether the check types run a rule reading the Actions settings of organizations.
+// Fetching them costs several requests per organization so they are only fetched when needed.
+func NeedsOrganizationActions(registry *types.CheckRegistry, checkTypes []types.CheckType) bool {
+	return selectsRule(registry, checkTypes, OrganizationEntityType, organizationActionsRules)
+}
+
+// NeedsRepositoryActions reports whether the check types run a rule reading the Actions settings of repositories.
+// Fetching them costs several requests per repository so they are only fetched when needed.
+func NeedsRepositoryActions(registry *types.CheckRegistry, checkTypes []types.CheckType) bool {
+	return selectsRule(registry, checkTypes, RepositoryEntityType, repositoryActionsRules)
+}
+
+func init() {
+	rules := append(append([]types.Rule{}, organizationActionsRules...), repositoryActionsRules...)
+	if err := types.DefaultRegistry.Register(rules...); err != nil {
+		panic(err)
+	}
+	for _, rule := range rules {
+		if err := types.DefaultRegistry.RegisterProfile(types.ActionsSecurity, rule.Id); err != nil {
+			panic(err)
+		}
+	}
+	registerControls(types.OpenSSFScorecard, "repository_actions_default_workflow_permissions", scorecardCheck("Token-Permissions"))
+}
@@ -0,0 +1,125 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubService_GetOrganizationActions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "org"}`)
+	})
+	mux.HandleFunc("/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 0, "custom_roles": []}`)
+	})
+	mux.HandleFunc("/orgs/org/actions/permissions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"enabled_repositories": "all", "allowed_actions": "all"}`)
+	})
+	mux.HandleFunc("/orgs/org/actions/permissions/workflow", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"default_workflow_permissions": "write", "can_approve_pull_request_reviews": true}`)
+	})
+	mux.HandleFunc("/orgs/org/actions/permissions/fork-pr-contributor-approval", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"approval_policy": "first_time_contributors_new_to_github"}`)
+	})
+	mux.HandleFunc("/orgs/org/actions/runner-groups", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "Must have admin rights"}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	org, err := gs.GetOrganization("org", OrganizationOptions{ActionsSettings: true})
+	assert.NoError(t, err)
+
+	report := org.Check(types.DefaultRegistry, []types.CheckType{types.ActionsSecurity})
+	// The runners could not be read so the profile errored, but the other settings are still reported
+	assert.Equal(t, types.Errored, report.Results[types.ActionsSecurity])
+	violations := report.Errors[0].Violations
+	assert.Equal(t, "allowed_actions is all. Expected it to be local_only or selected", violations["actions_allowed_actions"])
+	assert.Equal(t, "default_workflow_permissions is write. Expected it to be read", violations["actions_default_workflow_permissions"])
+	assert.Equal(t, "can_approve_pull_request_reviews is enabled. Expected it to be disabled", violations["actions_can_approve_pull_requests"])
+	assert.Contains(t, violations["actions_fork_pull_request_approval"], "first_time_contributors_new_to_github")
+	assert.NotContains(t, violations, "actions_runner_groups_public_repositories")
+	assert.Contains(t, report.Errors[0].Unevaluated["actions_runner_groups_public_repositories"], "unable to read the Actions runners")
+}
+
+func TestGithubService_GetRepositoriesActions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "public", "visibility": "public"}, {"name": "private", "visibility": "private"}]`)
+	})
+	mux.HandleFunc("/repos/org/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/public/actions/permissions", "/repos/org/private/actions/permissions":
+			fmt.Fprint(w, `{"enabled": true, "allowed_actions": "selected"}`)
+		case "/repos/org/public/actions/permissions/workflow", "/repos/org/private/actions/permissions/workflow":
+			fmt.Fprint(w, `{"default_workflow_permissions": "read", "can_approve_pull_request_reviews": false}`)
+		case "/repos/org/public/actions/permissions/fork-pr-contributor-approval":
+			fmt.Fprint(w, `{"approval_policy": "all_external_contributors"}`)
+		case "/repos/org/public/actions/runners":
+			fmt.Fprint(w, `{"total_count": 1, "runners": [{"id": 1, "name": "build-1"}]}`)
+		case "/repos/org/private/actions/permissions/fork-pr-contributor-approval", "/repos/org/private/actions/runners":
+			t.Errorf("unexpected request for a private repository: %s", r.URL.Path)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{ActionsSettings: true}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+
+	checkTypes := []types.CheckType{types.ActionsSecurity}
+	public := repos[0].Check(types.DefaultRegistry, checkTypes)
+	assert.Equal(t, types.Failed, public.Results[types.ActionsSecurity])
+	assert.Equal(t, map[string]string{
+		"repository_self_hosted_runners": "public repository has self-hosted runners build-1. Expected it to have none",
+	}, public.Errors[0].Violations)
+
+	private := repos[1].Check(types.DefaultRegistry, checkTypes)
+	assert.Equal(t, types.Passed, private.Results[types.ActionsSecurity])
+}
+
+func TestActionsRules_NotFetched(t *testing.T) {
+	repo := Repository{slug: "repo", Repository: &github.Repository{Visibility: github.String("private")}}
+
+	report := repo.Check(types.DefaultRegistry, []types.CheckType{types.ActionsSecurity})
+
+	assert.Equal(t, types.Errored, report.Results[types.ActionsSecurity])
+	assert.Equal(t, "the Actions settings were not fetched", report.Errors[0].Unevaluated["repository_actions_allowed_actions"])
+}
+
+func TestNeedsRepositoryActions(t *testing.T) {
+	assert.True(t, NeedsRepositoryActions(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails, types.ActionsSecurity}))
+	assert.False(t, NeedsRepositoryActions(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails, types.Membership}))
+}
+
+func TestNeedsOrganizationActions(t *testing.T) {
+	assert.True(t, NeedsOrganizationActions(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails, types.ActionsSecurity}))
+	assert.False(t, NeedsOrganizationActions(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails, types.SecurityAlerts}))
+}
+
+func TestGithubService_GetOrganizationWithoutActions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "org"}`)
+	})
+	mux.HandleFunc("/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 0, "custom_roles": []}`)
+	})
+	mux.HandleFunc("/orgs/org/actions/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s", r.URL.Path)
+	})
+	gs := newTestGithubService(t, mux)
+
+	org, err := gs.GetOrganization("org", OrganizationOptions{})
+
+	assert.NoError(t, err)
+	assert.Nil(t, org.actions)
+}
@@ -0,0 +1,319 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// Tools raising security alerts
+const (
+	DependabotAlerts     = "dependabot"
+	CodeScanningAlerts   = "code_scanning"
+	SecretScanningAlerts = "secret_scanning"
+)
+
+var AlertTools = []string{DependabotAlerts, CodeScanningAlerts, SecretScanningAlerts}
+
+var alertToolNames = map[string]string{
+	DependabotAlerts:     "Dependabot",
+	CodeScanningAlerts:   "code scanning",
+	SecretScanningAlerts: "secret scanning",
+}
+
+// Severities of the alerts, from the most to the least severe
+var AlertSeverities = []string{"critical", "high", "medium", "low"}
+
+// DefaultAlertMaxAges returns the number of days an open alert of a severity may stay open before it fails the alert rules.
+// Alerts of the severities without a maximum age never fail them.
+func DefaultAlertMaxAges() map[string]int {
+	return map[string]int{"critical": 30, "high": 90}
+}
+
+// ValidateAlertMaxAges checks that the maximum ages are set for known severities and are not negative
+func ValidateAlertMaxAges(maxAges map[string]int) error {
+	for severity, days := range maxAges {
+		if _, err := types.ParseSeverity(severity); err != nil {
+			return fmt.Errorf("invalid alert severity %q. Expected one of %s", severity, strings.Join(AlertSeverities, ", "))
+		}
+		if days < 0 {
+			return fmt.Errorf("the maximum age of %s alerts is %d days. Expected it to be at least 0", severity, days)
+		}
+	}
+	return nil
+}
+
+// A SecurityAlert is an open alert of a repository
+type SecurityAlert struct {
+	Number int `json:"number"`
+	// One of critical, high, medium or low. Secret scanning alerts have no severity and are critical, as a leaked secret can be used right away.
+	Severity  string    `json:"severity"`
+	CreatedAt time.Time `json:"created_at"`
+	Url       string    `json:"url,omitempty"`
+}
+
+// Age of the alert at the given time
+func (a SecurityAlert) Age(at time.Time) time.Duration {
+	return at.Sub(a.CreatedAt)
+}
+
+// RepositoryAlerts holds the open security alerts of a repository
+type RepositoryAlerts struct {
+	// Open alerts by tool
+	Open map[string][]SecurityAlert `json:"open,omitempty"`
+	// Tools that are not enabled for the repository. Whether they should be is checked by other rules.
+	Disabled []string `json:"disabled,omitempty"`
+	// Errors listing the alerts, by tool
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Alerts returns the open security alerts of the repository, or nil when they were not fetched
+func (r *Repository) Alerts() *RepositoryAlerts {
+	return r.alerts
+}
+
+// listAllAlerts requests every page of an alerts endpoint. Depending on the endpoint and the GitHub version,
+// the pages are numbered or follow a cursor.
+func listAllAlerts[T any](g *GithubService, fetch func(ctx context.Context, page int, after string) ([]T, *github.Response, error)) ([]T, error) {
+	var all []T
+	page, after := 0, ""
+	for {
+		ctx, cancelFn := g.requestContext()
+		alerts, resp, err := fetch(ctx, page, after)
+		cancelFn()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, alerts...)
+		switch {
+		case resp.NextPage != 0:
+			page = resp.NextPage
+		case resp.After != "":
+			after = resp.After
+		default:
+			return all, nil
+		}
+	}
+}
+
+// alertsDisabled reports whether the error means the tool is not enabled for the repository. GitHub answers 404 when
+// code scanning has no analysis or secret scanning is disabled, and 403 when Dependabot alerts or Advanced Security are disabled.
+// GitHub also answers 404 to tokens without access to the alerts, so only the messages about the tool count.
+func alertsDisabled(err error) bool {
+	var errResp *github.ErrorResponse
+	if !errors.As(err, &errResp) || errResp.Response == nil {
+		return false
+	}
+	message := strings.ToLower(errResp.Message)
+	switch errResp.Response.StatusCode {
+	case http.StatusNotFound:
+		return strings.Contains(message, "no analysis found") || strings.Contains(message, "disabled")
+	case http.StatusForbidden:
+		return strings.Contains(message, "disabled") || strings.Contains(message, "enabled")
+	}
+	return false
+}
+
+// codeScanningSeverity returns the security severity of the rule of a code scanning alert. Rules that are not about
+// security only have the severity of a linter, which is mapped to the closest security severity.
+func codeScanningSeverity(rule *github.Rule) string {
+	if level := rule.GetSecuritySeverityLevel(); level != "" {
+		return level
+	}
+	switch rule.GetSeverity() {
+	case "error":
+		return "high"
+	case "warning":
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+func (g *GithubService) listDependabotAlerts(owner string, repo string) ([]SecurityAlert, error) {
+	alerts, err := listAllAlerts(g, func(ctx context.Context, page int, after string) ([]*github.DependabotAlert, *github.Response, error) {
+		return g.client.Dependabot.ListRepoAlerts(ctx, owner, repo, &github.ListAlertsOptions{
+			State:             github.String("open"),
+			ListOptions:       github.ListOptions{Page: page},
+			ListCursorOptions: github.ListCursorOptions{PerPage: 100, After: after},
+		})
+	})
+	open := make([]SecurityAlert, 0, len(alerts))
+	for _, a := range alerts {
+		open = append(open, SecurityAlert{
+			Number:    a.GetNumber(),
+			Severity:  a.GetSecurityAdvisory().GetSeverity(),
+			CreatedAt: a.GetCreatedAt().Time,
+			Url:       a.GetHTMLURL(),
+		})
+	}
+	return open, err
+}
+
+func (g *GithubService) listCodeScanningAlerts(owner string, repo string) ([]SecurityAlert, error) {
+	alerts, err := listAllAlerts(g, func(ctx context.Context, page int, after string) ([]*github.Alert, *github.Response, error) {
+		return g.client.CodeScanning.ListAlertsForRepo(ctx, owner, repo, &github.AlertListOptions{
+			State:             "open",
+			ListOptions:       github.ListOptions{Page: page, PerPage: 100},
+			ListCursorOptions: github.ListCursorOptions{After: after},
+		})
+	})
+	open := make([]SecurityAlert, 0, len(alerts))
+	for _, a := range alerts {
+		open = append(open, SecurityAlert{
+			Number:    a.GetNumber(),
+			Severity:  codeScanningSeverity(a.GetRule()),
+			CreatedAt: a.GetCreatedAt().Time,
+			Url:       a.GetHTMLURL(),
+		})
+	}
+	return open, err
+}
+
+// The secrets of the alerts are not kept
+func (g *GithubService) listSecretScanningAlerts(owner string, repo string) ([]SecurityAlert, error) {
+	alerts, err := listAllAlerts(g, func(ctx context.Context, page int, after string) ([]*github.SecretScanningAlert, *github.Response, error) {
+		return g.client.SecretScanning.ListAlertsForRepo(ctx, owner, repo, &github.SecretScanningAlertListOptions{
+			State:             "open",
+			ListOptions:       github.ListOptions{Page: page, PerPage: 100},
+			ListCursorOptions: github.ListCursorOptions{After: after},
+		})
+	})
+	open := make([]SecurityAlert, 0, len(alerts))
+	for _, a := range alerts {
+		open = append(open, SecurityAlert{
+			Number:    a.GetNumber(),
+			Severity:  "critical",
+			CreatedAt: a.GetCreatedAt().Time,
+			Url:       a.GetHTMLURL(),
+		})
+	}
+	return open, err
+}
+
+// getRepositoryAlerts lists the open alerts of every tool. Errors are kept per tool and reported by the rules that need them.
+func (g *GithubService) getRepositoryAlerts(owner string, repo *github.Repository) *RepositoryAlerts {
+	alerts := &RepositoryAlerts{Open: make(map[string][]SecurityAlert)}
+	for _, tool := range AlertTools {
+		var open []SecurityAlert
+		var err error
+		switch tool {
+		case DependabotAlerts:
+			open, err = g.listDependabotAlerts(owner, repo.GetName())
+		case CodeScanningAlerts:
+			open, err = g.listCodeScanningAlerts(owner, repo.GetName())
+		case SecretScanningAlerts:
+			open, err = g.listSecretScanningAlerts(owner, repo.GetName())
+		}
+		switch {
+		case alertsDisabled(err):
+			alerts.Disabled = append(alerts.Disabled, tool)
+		case err != nil:
+			recordError(&alerts.Errors, tool, err)
+		case len(open) > 0:
+			alerts.Open[tool] = open
+		}
+	}
+	return alerts
+}
+
+// evaluateAlerts reports the severities with open alerts of the tool older than their maximum age
+func evaluateAlerts(repo *Repository, tool string) error {
+	if repo.alerts == nil {
+		return types.EvaluationErrorf("the security alerts of the repository were not fetched")
+	}
+	if message, ok := repo.alerts.Errors[tool]; ok {
+		return types.EvaluationErrorf("unable to list the %s alerts: %s", alertToolNames[tool], message)
+	}
+
+	violations := make(types.Violations)
+	for _, severity := range AlertSeverities {
+		maxAge, ok := repo.alertMaxAges[severity]
+		if !ok {
+			continue
+		}
+		var overdue []SecurityAlert
+		for _, a := range repo.alerts.Open[tool] {
+			if a.Severity == severity && a.Age(repo.ScannedAt()) > time.Duration(maxAge)*24*time.Hour {
+				overdue = append(overdue, a)
+			}
+		}
+		if len(overdue) == 0 {
+			continue
+		}
+		sort.Slice(overdue, func(i, j int) bool { return overdue[i].Number < overdue[j].Number })
+		numbers := make([]string, 0, len(overdue))
+		for _, a := range overdue {
+			numbers = append(numbers, fmt.Sprintf("#%d", a.Number))
+		}
+		violations[severity] = fmt.Sprintf("%d open %s %s alerts are older than %d days (%s). Expected none",
+			len(overdue), severity, alertToolNames[tool], maxAge, strings.Join(numbers, ", "))
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
+
+var alertRules = []types.Rule{
+	{
+		Id:          "dependabot_alerts",
+		Description: "No open Dependabot alert is older than the maximum age of its severity",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return evaluateAlerts(repo, DependabotAlerts)
+		}),
+	},
+	{
+		Id:          "code_scanning_alerts",
+		Description: "No open code scanning alert is older than the maximum age of its severity",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return evaluateAlerts(repo, CodeScanningAlerts)
+		}),
+	},
+	{
+		Id:          "secret_scanning_alerts",
+		Description: "No open secret scanning alert is older than the maximum age of critical alerts",
+		Severity:    types.Critical,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return evaluateAlerts(repo, SecretScanningAlerts)
+		}),
+	},
+}
+
+// The maximum ages of the options, or the default ones when none are set
+func (o RepositoryListOptions) alertMaxAges() map[string]int {
+	if o.AlertMaxAges == nil {
+		return DefaultAlertMaxAges()
+	}
+	return o.AlertMaxAges
+}
+
+// NeedsRepositoryAlerts reports whether the check types run a rule reading the security alerts of repositories.
+// Listing them costs several requests per repository so they are only fetched when needed.
+func NeedsRepositoryAlerts(registry *types.CheckRegistry, checkTypes []types.CheckType) bool {
+	return selectsRule(registry, checkTypes, RepositoryEntityType, alertRules)
+}
+
+func init() {
+	if err := types.DefaultRegistry.Register(alertRules...); err != nil {
+		panic(err)
+	}
+	for _, rule := range alertRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.SecurityAlerts, rule.Id); err != nil {
+			panic(err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubService_GetRepositoriesAlerts(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api", "default_branch": "main"}]`)
+	})
+	mux.HandleFunc("/repos/org/api/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/api/dependabot/alerts":
+			assert.Equal(t, "open", r.URL.Query().Get("state"))
+			if r.URL.Query().Get("after") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`<http://%s/repos/org/api/dependabot/alerts?per_page=100&after=cursor>; rel="next"`, r.Host))
+				fmt.Fprint(w, `[{"number": 3, "security_advisory": {"severity": "critical"}, "created_at": "2026-04-01T00:00:00Z"}]`)
+				return
+			}
+			assert.Equal(t, "cursor", r.URL.Query().Get("after"))
+			fmt.Fprint(w, `[{"number": 7, "security_advisory": {"severity": "high"}, "created_at": "2026-04-01T00:00:00Z"},
+				{"number": 9, "security_advisory": {"severity": "critical"}, "created_at": "2026-05-25T00:00:00Z"}]`)
+		case "/repos/org/api/code-scanning/alerts":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "no analysis found"}`)
+		case "/repos/org/api/secret-scanning/alerts":
+			fmt.Fprint(w, `[{"number": 1, "created_at": "2026-01-01T00:00:00Z", "secret": "ghp_leaked"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{Alerts: true}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+	alerts := repos[0].Alerts()
+	assert.Equal(t, []int{3, 7, 9}, []int{alerts.Open[DependabotAlerts][0].Number, alerts.Open[DependabotAlerts][1].Number, alerts.Open[DependabotAlerts][2].Number})
+	assert.Equal(t, []string{CodeScanningAlerts}, alerts.Disabled)
+	assert.Equal(t, []SecurityAlert{{Number: 1, Severity: "critical", CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}}, alerts.Open[SecretScanningAlerts])
+	assert.Empty(t, alerts.Errors)
+
+	report := repos[0].Check(types.DefaultRegistry, []types.CheckType{types.SecurityAlerts})
+	assert.Equal(t, types.Failed, report.Results[types.SecurityAlerts])
+	assert.Equal(t, map[string]string{
+		"dependabot_alerts.critical":      "1 open critical Dependabot alerts are older than 30 days (#3). Expected none",
+		"secret_scanning_alerts.critical": "1 open critical secret scanning alerts are older than 30 days (#1). Expected none",
+	}, report.Errors[0].Violations)
+}
+
+func TestEvaluateAlerts(t *testing.T) {
+	repo := &Repository{alertMaxAges: DefaultAlertMaxAges(), scannedAt: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), alerts: &RepositoryAlerts{Open: map[string][]SecurityAlert{
+		CodeScanningAlerts: {
+			{Number: 4, Severity: "medium", CreatedAt: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)},
+			{Number: 2, Severity: "medium", CreatedAt: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+			{Number: 1, Severity: "low", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}}}
+
+	assert.NoError(t, evaluateAlerts(repo, CodeScanningAlerts))
+
+	repo.alertMaxAges = map[string]int{"medium": 14}
+	err := evaluateAlerts(repo, CodeScanningAlerts)
+	assert.Equal(t, types.Violations{"medium": "2 open medium code scanning alerts are older than 14 days (#2, #4). Expected none"}, err)
+
+	repo.alerts.Errors = map[string]string{DependabotAlerts: "403 Resource not accessible by integration"}
+	assert.EqualError(t, evaluateAlerts(repo, DependabotAlerts), "unable to list the Dependabot alerts: 403 Resource not accessible by integration")
+	assert.EqualError(t, evaluateAlerts(&Repository{}, DependabotAlerts), "the security alerts of the repository were not fetched")
+}
+
+func TestValidateAlertMaxAges(t *testing.T) {
+	assert.NoError(t, ValidateAlertMaxAges(map[string]int{"critical": 0, "low": 365}))
+	assert.ErrorContains(t, ValidateAlertMaxAges(map[string]int{"severe": 30}), `invalid alert severity "severe"`)
+	assert.ErrorContains(t, ValidateAlertMaxAges(map[string]int{"high": -1}), "Expected it to be at least 0")
+}
+
+func TestCodeScanningSeverity(t *testing.T) {
+	assert.Equal(t, "critical", codeScanningSeverity(&github.Rule{Severity: github.String("error"), SecuritySeverityLevel: github.String("critical")}))
+	assert.Equal(t, "high", codeScanningSeverity(&github.Rule{Severity: github.String("error")}))
+	assert.Equal(t, "medium", codeScanningSeverity(&github.Rule{Severity: github.String("warning")}))
+	assert.Equal(t, "low", codeScanningSeverity(nil))
+}
+
+func TestAlertsDisabled(t *testing.T) {
+	errorResponse := func(status int, message string) error {
+		return &github.ErrorResponse{Response: &http.Response{StatusCode: status}, Message: message}
+	}
+
+	assert.True(t, alertsDisabled(errorResponse(http.StatusNotFound, "no analysis found")))
+	assert.True(t, alertsDisabled(errorResponse(http.StatusNotFound, "Secret scanning is disabled on this repository.")))
+	assert.True(t, alertsDisabled(errorResponse(http.StatusForbidden, "Dependabot alerts are disabled for this repository.")))
+	assert.True(t, alertsDisabled(errorResponse(http.StatusForbidden, "Advanced Security must be enabled for this repository to use code scanning.")))
+	// Tokens without the security_events scope or access to the repository also get a 404
+	assert.False(t, alertsDisabled(errorResponse(http.StatusNotFound, "Not Found")))
+	assert.False(t, alertsDisabled(errorResponse(http.StatusForbidden, "Resource not accessible by integration")))
+	assert.False(t, alertsDisabled(fmt.Errorf("timeout")))
+}
@@ -0,0 +1,164 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+)
+
+const (
+	// GitHub rejects app JWTs valid for more than 10 minutes
+	jwtLifetime = 9 * time.Minute
+	// Issue the JWT slightly in the past to allow for clock drift
+	jwtClockDrift = time.Minute
+	// Installation tokens are renewed this long before they expire
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// AppCredentials identify a GitHub App installation
+type AppCredentials struct {
+	AppId          int64
+	InstallationId int64
+	// PEM encoded private key of the app
+	PrivateKey []byte
+}
+
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("the GitHub App private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// appJWTTransport authenticates requests as the app itself. It is only used to mint installation tokens.
+type appJWTTransport struct {
+	base  http.RoundTripper
+	appId int64
+	key   *rsa.PrivateKey
+	now   func() time.Time
+}
+
+func (t *appJWTTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	jwt, err := t.signJWT()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	return t.base.RoundTrip(req)
+}
+
+func (t *appJWTTransport) signJWT() (string, error) {
+	now := t.now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]any{
+		"iat": now.Add(-jwtClockDrift).Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iss": fmt.Sprint(t.appId),
+	})
+
+	encoding := base64.RawURLEncoding
+	unsigned := encoding.EncodeToString(header) + "." + encoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, t.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("unable to sign the GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + encoding.EncodeToString(signature), nil
+}
+
+// installationTransport authenticates requests with an installation token, minting a new one
+// whenever the current token is about to expire.
+type installationTransport struct {
+	base           http.RoundTripper
+	appClient      *github.Client
+	installationId int64
+	now            func() time.Time
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+func (t *installationTransport) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && t.now().Add(tokenRefreshMargin).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	token, _, err := t.appClient.Apps.CreateInstallationToken(ctx, t.installationId, nil)
+	if err != nil {
+		return "", fmt.Errorf("unable to create an installation token for installation %d: %w", t.installationId, err)
+	}
+	t.token = token.GetToken()
+	t.expiresAt = token.GetExpiresAt().Time
+	return t.token, nil
+}
+
+func (t *installationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+token)
+	return t.base.RoundTrip(req)
+}
+
+// NewGithubAppService creates a service authenticated as a GitHub App installation
+func NewGithubAppService(credentials AppCredentials, options ServiceOptions) (IGithubService, error) {
+	key, err := ParsePrivateKey(credentials.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	options = options.withDefaults()
+	rateLimited := newRateLimitTransport(http.DefaultTransport, options.MaxRetries, options.RequestTimeout)
+	appClient, err := withBaseURL(github.NewClient(&http.Client{
+		Transport: &appJWTTransport{base: rateLimited, appId: credentials.AppId, key: key, now: time.Now},
+	}), options.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := withBaseURL(github.NewClient(&http.Client{
+		Transport: &installationTransport{
+			base:           rateLimited,
+			appClient:      appClient,
+			installationId: credentials.InstallationId,
+			now:            time.Now,
+		},
+	}), options.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return newGithubService(client, options), nil
+}
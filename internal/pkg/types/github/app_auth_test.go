@@ -0,0 +1,122 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+// Stands in for the GitHub Enterprise API, minting installation tokens valid for tokenLifetime from the time of clock
+func newAppTestServer(t *testing.T, key *rsa.PrivateKey, tokenLifetime time.Duration, clock func() time.Time, mints *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v3/app/installations/42/access_tokens", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		jwt := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		assert.Len(t, parts, 3)
+
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		assert.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature))
+
+		var claims map[string]any
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		json.Unmarshal(payload, &claims)
+		assert.Equal(t, "7", claims["iss"])
+
+		n := atomic.AddInt32(mints, 1)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token": "ghs_%d", "expires_at": %q}`, n, clock().Add(tokenLifetime).Format(time.RFC3339))
+	})
+	mux.HandleFunc("/api/v3/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"login": "org", "description": %q}`, r.Header.Get("Authorization"))
+	})
+	mux.HandleFunc("/api/v3/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 0, "custom_roles": []}`)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestAppCredentials(t *testing.T) (*rsa.PrivateKey, AppCredentials) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return key, AppCredentials{AppId: 7, InstallationId: 42, PrivateKey: privateKey}
+}
+
+func TestGithubAppService_ReusesValidToken(t *testing.T) {
+	key, credentials := newTestAppCredentials(t)
+	var mints int32
+	server := newAppTestServer(t, key, time.Hour, time.Now, &mints)
+
+	gs, err := NewGithubAppService(credentials, ServiceOptions{BaseURL: server.URL})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		org, err := gs.GetOrganization("org", OrganizationOptions{ActionsSettings: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "token ghs_1", org.GetDescription())
+	}
+	assert.Equal(t, int32(1), mints)
+}
+
+func TestInstallationTransport_RefreshesExpiringToken(t *testing.T) {
+	key, credentials := newTestAppCredentials(t)
+	var mints int32
+	clock := time.Now()
+	server := newAppTestServer(t, key, time.Hour, func() time.Time { return clock }, &mints)
+
+	appClient, err := withBaseURL(github.NewClient(&http.Client{
+		Transport: &appJWTTransport{base: http.DefaultTransport, appId: credentials.AppId, key: key, now: time.Now},
+	}), server.URL)
+	assert.NoError(t, err)
+	client, err := withBaseURL(github.NewClient(&http.Client{
+		Transport: &installationTransport{
+			base:           http.DefaultTransport,
+			appClient:      appClient,
+			installationId: credentials.InstallationId,
+			now:            func() time.Time { return clock },
+		},
+	}), server.URL)
+	assert.NoError(t, err)
+	authorization := func() string {
+		org, _, err := client.Organizations.Get(context.Background(), "org")
+		assert.NoError(t, err)
+		return org.GetDescription()
+	}
+
+	assert.Equal(t, "token ghs_1", authorization())
+	assert.Equal(t, "token ghs_1", authorization())
+	assert.Equal(t, int32(1), mints)
+
+	// Within the refresh margin of the expiry
+	clock = clock.Add(time.Hour - tokenRefreshMargin + time.Second)
+	assert.Equal(t, "token ghs_2", authorization())
+	assert.Equal(t, int32(2), mints)
+	assert.Equal(t, "token ghs_2", authorization())
+	assert.Equal(t, int32(2), mints)
+}
+
+func TestGithubAppService_InvalidPrivateKey(t *testing.T) {
+	_, err := NewGithubAppService(AppCredentials{AppId: 7, InstallationId: 42, PrivateKey: []byte("not a key")}, ServiceOptions{})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package github
+
+import (
+	"gh_foundations/internal/pkg/types"
+	"strings"
+)
+
+const (
+	cisBenchmarkUrl  = "https://www.cisecurity.org/benchmark/software-supply-chain_security"
+	scorecardDocsUrl = "https://github.com/ossf/scorecard/blob/main/docs/checks.md"
+)
+
+// cisControls returns the controls of the CIS Software Supply Chain Security Guide with the given ids
+func cisControls(ids ...string) []types.Control {
+	controls := make([]types.Control, 0, len(ids))
+	for _, id := range ids {
+		controls = append(controls, types.Control{Id: "CIS " + id, Url: cisBenchmarkUrl})
+	}
+	return controls
+}
+
+// scorecardCheck returns the OpenSSF Scorecard check with the given name, e.g. Branch-Protection
+func scorecardCheck(name string) types.Control {
+	return types.Control{Id: "Scorecard " + name, Url: scorecardDocsUrl + "#" + strings.ToLower(name)}
+}
+
+// registerControls adds the rule to the profile of a benchmark and maps it to the controls of the benchmark
+func registerControls(checkType types.CheckType, ruleId string, controls ...types.Control) {
+	if err := types.DefaultRegistry.RegisterProfile(checkType, ruleId); err != nil {
+		panic(err)
+	}
+	if err := types.DefaultRegistry.MapControls(checkType, ruleId, controls...); err != nil {
+		panic(err)
+	}
+}
+
+// selectsRule reports whether the check types run any of the rules
+func selectsRule(registry *types.CheckRegistry, checkTypes []types.CheckType, entityType string, rules []types.Rule) bool {
+	for _, t := range checkTypes {
+		for _, selected := range registry.RulesFor(t, entityType) {
+			for _, rule := range rules {
+				if selected.Id == rule.Id {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"slices"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// A BranchProtectionRequirement describes the classic branch protection settings a branch must at least have.
+// Boolean settings set to false are not required.
+type BranchProtectionRequirement struct {
+	RequiredApprovingReviewCount int
+	DismissStaleReviews          bool
+	RequireCodeOwnerReviews      bool
+	RequireStatusChecks          bool
+	RequireUpToDateBranches      bool
+	RequireSignedCommits         bool
+	EnforceAdmins                bool
+	DisallowForcePushes          bool
+	DisallowDeletions            bool
+}
+
+var defaultBranchProtectionRequirement = BranchProtectionRequirement{
+	RequiredApprovingReviewCount: 1,
+	DismissStaleReviews:          true,
+	RequireStatusChecks:          true,
+	RequireSignedCommits:         true,
+	EnforceAdmins:                true,
+	DisallowForcePushes:          true,
+	DisallowDeletions:            true,
+}
+
+// Evaluate reports every setting of the protection that is missing or weaker than required, keyed by setting
+func (req *BranchProtectionRequirement) Evaluate(protection *github.Protection) types.Violations {
+	violations := make(types.Violations)
+	if protection == nil {
+		violations["protected"] = "branch protection is not enabled. Expected the branch to be protected"
+		return violations
+	}
+
+	reviews := protection.RequiredPullRequestReviews
+	if reviews == nil {
+		reviews = &github.PullRequestReviewsEnforcement{}
+	}
+	if reviews.RequiredApprovingReviewCount < req.RequiredApprovingReviewCount {
+		violations["required_approving_review_count"] = fmt.Sprintf("required_approving_review_count is %d. Expected it to be at least %d",
+			reviews.RequiredApprovingReviewCount, req.RequiredApprovingReviewCount)
+	}
+	requireEnabled(violations, "dismiss_stale_reviews", req.DismissStaleReviews, reviews.DismissStaleReviews)
+	requireEnabled(violations, "require_code_owner_reviews", req.RequireCodeOwnerReviews, reviews.RequireCodeOwnerReviews)
+
+	statusChecks := protection.RequiredStatusChecks
+	requireEnabled(violations, "required_status_checks", req.RequireStatusChecks, statusChecks != nil)
+	requireEnabled(violations, "strict_status_checks", req.RequireUpToDateBranches, statusChecks != nil && statusChecks.Strict)
+
+	signatures := protection.RequiredSignatures
+	requireEnabled(violations, "required_signatures", req.RequireSignedCommits, signatures != nil && signatures.GetEnabled())
+	requireEnabled(violations, "enforce_admins", req.EnforceAdmins, protection.EnforceAdmins != nil && protection.EnforceAdmins.Enabled)
+
+	if req.DisallowForcePushes && protection.AllowForcePushes != nil && protection.AllowForcePushes.Enabled {
+		violations["allow_force_pushes"] = "allow_force_pushes is enabled. Expected it to be disabled"
+	}
+	if req.DisallowDeletions && protection.AllowDeletions != nil && protection.AllowDeletions.Enabled {
+		violations["allow_deletions"] = "allow_deletions is enabled. Expected it to be disabled"
+	}
+	return violations
+}
+
+// protectedBranches returns the default branch followed by the extra branches, without duplicates
+func (r *Repository) protectedBranches(extraBranches []string) []string {
+	var branches []string
+	if r.GetDefaultBranch() != "" {
+		branches = append(branches, r.GetDefaultBranch())
+	}
+	for _, branch := range extraBranches {
+		if !slices.Contains(branches, branch) {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+var branchProtectionRules = []types.Rule{
+	{
+		Id:          "branch_protection",
+		Description: "The default branch and the branches protected in the HCL inputs have classic branch protection",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if repo.branchProtections == nil {
+				return types.EvaluationErrorf("the branch protection of the repository was not fetched")
+			}
+			violations := make(types.Violations)
+			for branch, protection := range repo.branchProtections {
+				for setting, message := range defaultBranchProtectionRequirement.Evaluate(protection) {
+					violations[branch+"."+setting] = fmt.Sprintf("branch %s: %s", branch, message)
+				}
+			}
+			// The branches whose protection could not be read are not evaluated, the others still are
+			var unreadable []string
+			for branch, message := range repo.branchProtectionErrors {
+				unreadable = append(unreadable, fmt.Sprintf("branch %s: %s", branch, message))
+			}
+			return partialEvaluation("the branch protection", unreadable, violations)
+		}),
+	},
+}
+
+// NeedsBranchProtection reports whether the check types run a rule reading the classic branch protection of repositories.
+// Reading it costs one request per branch and needs admin access, so it is only fetched when needed.
+func NeedsBranchProtection(registry *types.CheckRegistry, checkTypes []types.CheckType) bool {
+	return selectsRule(registry, checkTypes, RepositoryEntityType, branchProtectionRules) || selectsRule(registry, checkTypes, RepositoryEntityType, codeReviewRules)
+}
+
+func init() {
+	if err := types.DefaultRegistry.Register(branchProtectionRules...); err != nil {
+		panic(err)
+	}
+	for _, rule := range branchProtectionRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.BranchProtection, rule.Id); err != nil {
+			panic(err)
+		}
+	}
+	registerControls(types.OpenSSFScorecard, "branch_protection", scorecardCheck("Branch-Protection"))
+	// Stale reviews, status checks, signed commits, admin enforcement, force pushes and deletions
+	registerControls(types.CISSoftwareSupplyChain, "branch_protection", cisControls("1.1.4", "1.1.9", "1.1.12", "1.1.14", "1.1.16", "1.1.17")...)
+}
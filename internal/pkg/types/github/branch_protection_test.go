@@ -0,0 +1,95 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchProtectionRequirement_Compliant(t *testing.T) {
+	protection := &github.Protection{
+		RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{RequiredApprovingReviewCount: 2, DismissStaleReviews: true},
+		RequiredStatusChecks:       &github.RequiredStatusChecks{Strict: true},
+		RequiredSignatures:         &github.SignaturesProtectedBranch{Enabled: github.Bool(true)},
+		EnforceAdmins:              &github.AdminEnforcement{Enabled: true},
+		AllowForcePushes:           &github.AllowForcePushes{Enabled: false},
+		AllowDeletions:             &github.AllowDeletions{Enabled: false},
+	}
+
+	assert.Empty(t, defaultBranchProtectionRequirement.Evaluate(protection))
+}
+
+func TestBranchProtectionRequirement_Weaker(t *testing.T) {
+	protection := &github.Protection{
+		AllowForcePushes: &github.AllowForcePushes{Enabled: true},
+		AllowDeletions:   &github.AllowDeletions{Enabled: true},
+	}
+
+	violations := defaultBranchProtectionRequirement.Evaluate(protection)
+
+	assert.Equal(t, types.Violations{
+		"required_approving_review_count": "required_approving_review_count is 0. Expected it to be at least 1",
+		"dismiss_stale_reviews":           "dismiss_stale_reviews is not enabled. Expected it to be enabled",
+		"required_status_checks":          "required_status_checks is not enabled. Expected it to be enabled",
+		"required_signatures":             "required_signatures is not enabled. Expected it to be enabled",
+		"enforce_admins":                  "enforce_admins is not enabled. Expected it to be enabled",
+		"allow_force_pushes":              "allow_force_pushes is enabled. Expected it to be disabled",
+		"allow_deletions":                 "allow_deletions is enabled. Expected it to be disabled",
+	}, violations)
+}
+
+func TestGithubService_GetRepositoriesBranchProtection(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api", "default_branch": "main"}]`)
+	})
+	mux.HandleFunc("/repos/org/api/rules/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/org/api/branches/main/protection", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{
+			"required_pull_request_reviews": {"required_approving_review_count": 1, "dismiss_stale_reviews": true},
+			"required_status_checks": {"strict": false, "contexts": ["build"]},
+			"required_signatures": {"enabled": true},
+			"enforce_admins": {"enabled": true},
+			"allow_force_pushes": {"enabled": false},
+			"allow_deletions": {"enabled": false}
+		}`)
+	})
+	mux.HandleFunc("/repos/org/api/branches/release/v1/protection", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Branch not protected"}`)
+	})
+	mux.HandleFunc("/repos/org/api/branches/develop/protection", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{
+		BranchProtection:  true,
+		ProtectedBranches: map[string][]string{"org/api": {"main", "release/v1", "develop"}},
+	}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+
+	report := repos[0].Check(types.DefaultRegistry, []types.CheckType{types.BranchProtection})
+
+	assert.Equal(t, types.Errored, report.Results[types.BranchProtection])
+	assert.Equal(t, map[string]string{
+		"branch_protection.release/v1.protected": "branch release/v1: branch protection is not enabled. Expected the branch to be protected",
+	}, report.Errors[0].Violations)
+	assert.Contains(t, report.Errors[0].Unevaluated["branch_protection"], "unable to read the branch protection: branch develop:")
+}
+
+func TestNeedsBranchProtection(t *testing.T) {
+	assert.True(t, NeedsBranchProtection(types.DefaultRegistry, []types.CheckType{types.BranchProtection}))
+	// The code review rules also read the reviews required by the classic branch protection
+	assert.True(t, NeedsBranchProtection(types.DefaultRegistry, []types.CheckType{types.OpenSSFScorecard}))
+	assert.False(t, NeedsBranchProtection(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails}))
+	assert.False(t, NeedsBranchProtection(types.DefaultRegistry, []types.CheckType{types.SecurityAlerts}))
+}
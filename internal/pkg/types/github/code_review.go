@@ -0,0 +1,63 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+)
+
+// requiredApprovals returns the number of approving reviews needed to merge into the default branch.
+// Repository rulesets, organization rulesets and classic branch protection all apply, so the strictest one wins.
+func (r *Repository) requiredApprovals() int {
+	approvals := 0
+	for _, rule := range r.rulesets {
+		if rule.Type != "pull_request" {
+			continue
+		}
+		var params struct {
+			RequiredApprovingReviewCount int `json:"required_approving_review_count"`
+		}
+		if decodeParameters(rule, &params) {
+			approvals = max(approvals, params.RequiredApprovingReviewCount)
+		}
+	}
+	if reviews := r.branchProtections[r.GetDefaultBranch()].GetRequiredPullRequestReviews(); reviews != nil {
+		approvals = max(approvals, reviews.RequiredApprovingReviewCount)
+	}
+	return approvals
+}
+
+func codeReviewRule(id string, description string, minApprovals int) types.Rule {
+	return types.Rule{
+		Id:          id,
+		Description: description,
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			approvals := repo.requiredApprovals()
+			if approvals >= minApprovals {
+				return nil
+			}
+			// The rulesets or the classic branch protection may require the reviews that the other does not
+			if repo.rulesetsError != "" {
+				return types.EvaluationErrorf("unable to read the rules of the default branch: %s", repo.rulesetsError)
+			}
+			if message, ok := repo.branchProtectionErrors[repo.GetDefaultBranch()]; ok {
+				return types.EvaluationErrorf("unable to read the branch protection of %s: %s", repo.GetDefaultBranch(), message)
+			}
+			return fmt.Errorf("required_approving_review_count of the default branch is %d. Expected it to be at least %d", approvals, minApprovals)
+		}),
+	}
+}
+
+var codeReviewRules = []types.Rule{
+	codeReviewRule("code_review", "Changes to the default branch need an approving review", 1),
+	codeReviewRule("code_review_two_approvals", "Changes to the default branch need two approving reviews", 2),
+}
+
+func init() {
+	if err := types.DefaultRegistry.Register(codeReviewRules...); err != nil {
+		panic(err)
+	}
+	registerControls(types.OpenSSFScorecard, "code_review", scorecardCheck("Code-Review"))
+	registerControls(types.CISSoftwareSupplyChain, "code_review_two_approvals", cisControls("1.1.3")...)
+}
@@ -0,0 +1,253 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+)
+
+const workflowsDirectory = ".github/workflows"
+
+// Directories GitHub looks into for community health files such as SECURITY.md, the root directory being ""
+var communityDirectories = []string{"", ".github", "docs"}
+
+// RepositoryContents holds the files of the default branch of a repository that are read by the rules
+type RepositoryContents struct {
+	// Paths of the files in the root, .github and docs directories
+	Files []string `json:"files,omitempty"`
+	// Content of the workflows, by path
+	Workflows map[string]string `json:"workflows,omitempty"`
+	// CODEOWNERS file used by GitHub, if any
+	Codeowners *Codeowners `json:"codeowners,omitempty"`
+	// Errors reading a directory, a workflow or the CODEOWNERS file, by path
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// listDirectory lists the entries of a directory of the default branch. Missing directories and empty repositories have no entries.
+func (g *GithubService) listDirectory(owner string, repo string, dir string) ([]*github.RepositoryContent, error) {
+	ctx, cancelFn := g.requestContext()
+	defer cancelFn()
+	_, entries, resp, err := g.client.Repositories.GetContents(ctx, owner, repo, dir, nil)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	return entries, err
+}
+
+func (g *GithubService) getFile(owner string, repo string, path string) (string, error) {
+	ctx, cancelFn := g.requestContext()
+	defer cancelFn()
+	file, _, _, err := g.client.Repositories.GetContents(ctx, owner, repo, path, nil)
+	if err != nil {
+		return "", err
+	}
+	return file.GetContent()
+}
+
+// getRepositoryContents lists the community health directories and reads every workflow and the CODEOWNERS file of the repository.
+// Errors are kept per path and reported by the rules that need them.
+func (g *GithubService) getRepositoryContents(owner string, repo *github.Repository) *RepositoryContents {
+	contents := &RepositoryContents{}
+	for _, dir := range communityDirectories {
+		entries, err := g.listDirectory(owner, repo.GetName(), dir)
+		if err != nil {
+			recordError(&contents.Errors, path.Clean(dir), err)
+			continue
+		}
+		for _, entry := range entries {
+			if entry.GetType() == "file" {
+				contents.Files = append(contents.Files, entry.GetPath())
+			}
+		}
+	}
+
+	if p, ok := contents.findFile(codeownersFiles...); ok {
+		codeowners, err := g.getCodeowners(owner, repo.GetName(), p)
+		if err != nil {
+			recordError(&contents.Errors, p, err)
+		}
+		contents.Codeowners = codeowners
+	}
+
+	entries, err := g.listDirectory(owner, repo.GetName(), workflowsDirectory)
+	if err != nil {
+		recordError(&contents.Errors, workflowsDirectory, err)
+	}
+	for _, entry := range entries {
+		ext := path.Ext(entry.GetName())
+		if entry.GetType() != "file" || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		content, err := g.getFile(owner, repo.GetName(), entry.GetPath())
+		if err != nil {
+			recordError(&contents.Errors, entry.GetPath(), err)
+			continue
+		}
+		if contents.Workflows == nil {
+			contents.Workflows = make(map[string]string)
+		}
+		contents.Workflows[entry.GetPath()] = content
+	}
+	return contents
+}
+
+// findFile returns the first file of the repository matching one of the paths, ignoring case
+func (c *RepositoryContents) findFile(paths ...string) (string, bool) {
+	for _, p := range paths {
+		for _, file := range c.Files {
+			if strings.EqualFold(file, p) {
+				return file, true
+			}
+		}
+	}
+	return "", false
+}
+
+// directoryErrors joins the errors listing the community health directories
+func (c *RepositoryContents) directoryErrors() error {
+	var errs []string
+	for _, dir := range communityDirectories {
+		if message, ok := c.Errors[path.Clean(dir)]; ok {
+			errs = append(errs, fmt.Sprintf("%s: %s", path.Clean(dir), message))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return types.EvaluationErrorf("unable to list the files of the repository: %s", strings.Join(errs, "; "))
+}
+
+// inCommunityDirectories returns the paths of the names in every community health directory
+func inCommunityDirectories(names ...string) []string {
+	var paths []string
+	for _, dir := range communityDirectories {
+		for _, name := range names {
+			paths = append(paths, path.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+var (
+	securityPolicyFiles       = inCommunityDirectories("SECURITY.md", "SECURITY.markdown", "SECURITY.rst", "SECURITY.adoc")
+	dependencyUpdateToolFiles = []string{
+		".github/dependabot.yml",
+		".github/dependabot.yaml",
+		"renovate.json",
+		"renovate.json5",
+		".renovaterc",
+		".renovaterc.json",
+		".github/renovate.json",
+		".github/renovate.json5",
+	}
+)
+
+// requireFile passes when the repository has one of the files and describes the missing file otherwise
+func requireFile(repo *Repository, description string, paths []string) error {
+	if repo.contents == nil {
+		return types.EvaluationErrorf("the contents of the repository were not fetched")
+	}
+	if _, ok := repo.contents.findFile(paths...); ok {
+		return nil
+	}
+	if err := repo.contents.directoryErrors(); err != nil {
+		return err
+	}
+	return fmt.Errorf("%s not found. Expected one of %s", description, strings.Join(paths, ", "))
+}
+
+var contentsRules = []types.Rule{
+	{
+		Id:          "security_policy",
+		Description: "The repository has a security policy",
+		Severity:    types.Medium,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return requireFile(repo, "security policy", securityPolicyFiles)
+		}),
+	},
+	{
+		Id:          "dependency_update_tool",
+		Description: "Dependabot or Renovate keeps the dependencies of the repository up to date",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return requireFile(repo, "dependency update tool configuration", dependencyUpdateToolFiles)
+		}),
+	},
+	{
+		Id:          "dangerous_workflow",
+		Description: "Workflows do not run untrusted code with access to secrets",
+		Severity:    types.Critical,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return evaluateWorkflows(repo, dangerousWorkflowPatterns)
+		}),
+	},
+	{
+		Id:          "workflow_token_permissions",
+		Description: "Workflows declare read only top-level permissions for the GITHUB_TOKEN",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			return evaluateWorkflows(repo, workflowTokenPermissions)
+		}),
+	},
+}
+
+// evaluateWorkflows runs the evaluation against every workflow of the repository and collects the violations.
+// Violations are keyed by the file name of the workflow followed by the key returned by the evaluation, if any.
+func evaluateWorkflows(repo *Repository, evaluate func(w *workflow) map[string]string) error {
+	if repo.contents == nil {
+		return types.EvaluationErrorf("the contents of the repository were not fetched")
+	}
+
+	// The workflows that could not be read are not evaluated, the others still are
+	var unreadable []string
+	for p, message := range repo.contents.Errors {
+		if p == workflowsDirectory || path.Dir(p) == workflowsDirectory {
+			unreadable = append(unreadable, fmt.Sprintf("%s: %s", p, message))
+		}
+	}
+
+	violations := make(types.Violations)
+
+	for p, content := range repo.contents.Workflows {
+		name := path.Base(p)
+		w, err := parseWorkflow(content)
+		if err != nil {
+			violations[name] = fmt.Sprintf("unable to parse %s: %s", p, err)
+			continue
+		}
+		for key, message := range evaluate(w) {
+			if key != "" {
+				key = name + "." + key
+			} else {
+				key = name
+			}
+			violations[key] = fmt.Sprintf("workflow %s: %s", name, message)
+		}
+	}
+	return partialEvaluation("the workflows", unreadable, violations)
+}
+
+// NeedsRepositoryContents reports whether the check types run a rule reading the files of repositories.
+// Reading them costs several requests per repository so they are only fetched when needed.
+func NeedsRepositoryContents(registry *types.CheckRegistry, checkTypes []types.CheckType) bool {
+	return selectsRule(registry, checkTypes, RepositoryEntityType, contentsRules) || selectsRule(registry, checkTypes, RepositoryEntityType, hygieneRules)
+}
+
+func init() {
+	if err := types.DefaultRegistry.Register(contentsRules...); err != nil {
+		panic(err)
+	}
+	registerControls(types.OpenSSFScorecard, "dangerous_workflow", scorecardCheck("Dangerous-Workflow"))
+	registerControls(types.OpenSSFScorecard, "workflow_token_permissions", scorecardCheck("Token-Permissions"))
+	registerControls(types.OpenSSFScorecard, "security_policy", scorecardCheck("Security-Policy"))
+	registerControls(types.OpenSSFScorecard, "dependency_update_tool", scorecardCheck("Dependency-Update-Tool"))
+	registerControls(types.CISSoftwareSupplyChain, "security_policy", cisControls("1.2.1")...)
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"encoding/base64"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubService_GetRepositoriesContents(t *testing.T) {
+	workflow := base64.StdEncoding.EncodeToString([]byte(privilegedWorkflow))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api", "default_branch": "main"}]`)
+	})
+	mux.HandleFunc("/repos/org/api/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/api/contents/":
+			fmt.Fprint(w, `[{"type": "file", "name": "README.md", "path": "README.md"}, {"type": "dir", "name": ".github", "path": ".github"}]`)
+		case "/repos/org/api/contents/.github":
+			fmt.Fprint(w, `[{"type": "file", "name": "security.md", "path": ".github/security.md"}]`)
+		case "/repos/org/api/contents/.github/workflows":
+			fmt.Fprint(w, `[{"type": "file", "name": "ci.yml", "path": ".github/workflows/ci.yml"}, {"type": "file", "name": "notes.txt", "path": ".github/workflows/notes.txt"}]`)
+		case "/repos/org/api/contents/.github/workflows/ci.yml":
+			fmt.Fprintf(w, `{"type": "file", "name": "ci.yml", "path": ".github/workflows/ci.yml", "encoding": "base64", "content": %q}`, workflow)
+		case "/repos/org/api/contents/.github/workflows/notes.txt":
+			t.Error("unexpected request for a file that is not a workflow")
+		case "/repos/org/api/rules/branches/main":
+			fmt.Fprint(w, `[{"type": "pull_request", "ruleset_source_type": "Repository", "parameters": {"required_approving_review_count": 1}}]`)
+		case "/repos/org/api/branches/main/protection":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Branch not protected"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{BranchProtection: true, Contents: true}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+	assert.Equal(t, []string{"README.md", ".github/security.md"}, repos[0].contents.Files)
+	assert.Len(t, repos[0].contents.Workflows, 1)
+
+	checkTypes := []types.CheckType{types.OpenSSFScorecard, types.CISSoftwareSupplyChain}
+	report := repos[0].Check(types.DefaultRegistry, checkTypes)
+	var scorecard, cis types.CheckError
+	for _, e := range report.Errors {
+		if e.Check == types.OpenSSFScorecard {
+			scorecard = e
+		} else {
+			cis = e
+		}
+	}
+
+	assert.NotContains(t, scorecard.Violations, "security_policy")
+	assert.NotContains(t, scorecard.Violations, "code_review")
+	assert.Contains(t, scorecard.Violations["dependency_update_tool"], "dependency update tool configuration not found")
+	assert.Contains(t, scorecard.Violations, "dangerous_workflow.ci.yml.untrusted_checkout")
+	assert.Contains(t, scorecard.Violations, "dangerous_workflow.ci.yml.script_injection")
+	assert.Equal(t, "workflow ci.yml: top-level permissions are write-all. Expected them to be read only", scorecard.Violations["workflow_token_permissions.ci.yml"])
+	assert.Equal(t, []types.Control{scorecardCheck("Dangerous-Workflow")}, scorecard.Controls["dangerous_workflow.ci.yml.script_injection"])
+	assert.Equal(t, "https://github.com/ossf/scorecard/blob/main/docs/checks.md#dangerous-workflow", scorecard.Controls["dangerous_workflow.ci.yml.script_injection"][0].Url)
+
+	assert.Equal(t, "required_approving_review_count of the default branch is 1. Expected it to be at least 2", cis.Violations["code_review_two_approvals"])
+	assert.Equal(t, cisControls("1.1.3"), cis.Controls["code_review_two_approvals"])
+}
+
+func TestContentsRules_NotFetched(t *testing.T) {
+	repo := Repository{slug: "repo"}
+
+	assert.EqualError(t, requireFile(&repo, "security policy", securityPolicyFiles), "the contents of the repository were not fetched")
+}
+
+func TestContentsRules_DirectoryError(t *testing.T) {
+	repo := Repository{slug: "repo", contents: &RepositoryContents{Errors: map[string]string{".": "403 Forbidden"}}}
+
+	err := requireFile(&repo, "security policy", securityPolicyFiles)
+
+	assert.EqualError(t, err, "unable to list the files of the repository: .: 403 Forbidden")
+	var evaluationError *types.EvaluationError
+	assert.ErrorAs(t, err, &evaluationError)
+}
+
+func TestNeedsRepositoryContents(t *testing.T) {
+	assert.True(t, NeedsRepositoryContents(types.DefaultRegistry, []types.CheckType{types.OpenSSFScorecard}))
+	assert.True(t, NeedsRepositoryContents(types.DefaultRegistry, []types.CheckType{types.CISSoftwareSupplyChain}))
+	assert.False(t, NeedsRepositoryContents(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails}))
+}
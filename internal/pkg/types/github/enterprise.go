@@ -0,0 +1,87 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The REST API does not list the organizations of an enterprise, only the GraphQL API does
+const enterpriseOrganizationsQuery = `query($slug: String!, $cursor: String) {
+  enterprise(slug: $slug) {
+    organizations(first: 100, after: $cursor) {
+      nodes { login }
+      pageInfo { hasNextPage endCursor }
+    }
+  }
+}`
+
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type enterpriseOrganizationsResponse struct {
+	Data struct {
+		Enterprise *struct {
+			Organizations struct {
+				Nodes []struct {
+					Login string `json:"login"`
+				} `json:"nodes"`
+				PageInfo struct {
+					HasNextPage bool   `json:"hasNextPage"`
+					EndCursor   string `json:"endCursor"`
+				} `json:"pageInfo"`
+			} `json:"organizations"`
+		} `json:"enterprise"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphqlURL is relative to the base URL of the REST API. GitHub Enterprise Server serves GraphQL
+// from /api/graphql rather than below the /api/v3/ REST prefix.
+func (g *GithubService) graphqlURL() string {
+	if strings.HasSuffix(g.client.BaseURL.Path, "/api/v3/") {
+		return "../graphql"
+	}
+	return "graphql"
+}
+
+// GetEnterpriseOrganizations returns the login of every organization of the enterprise
+func (g *GithubService) GetEnterpriseOrganizations(enterprise string) ([]string, error) {
+	var slugs []string
+	var cursor *string
+	for {
+		req, err := g.client.NewRequest("POST", g.graphqlURL(), graphqlRequest{
+			Query:     enterpriseOrganizationsQuery,
+			Variables: map[string]any{"slug": enterprise, "cursor": cursor},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var resp enterpriseOrganizationsResponse
+		ctx, cancelFn := g.requestContext()
+		_, err = g.client.Do(ctx, req, &resp)
+		cancelFn()
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the organizations of enterprise %q: %w", enterprise, err)
+		}
+		if len(resp.Errors) > 0 {
+			return nil, fmt.Errorf("unable to list the organizations of enterprise %q: %s", enterprise, resp.Errors[0].Message)
+		}
+		if resp.Data.Enterprise == nil {
+			return nil, fmt.Errorf("enterprise %q not found", enterprise)
+		}
+
+		organizations := resp.Data.Enterprise.Organizations
+		for _, node := range organizations.Nodes {
+			slugs = append(slugs, node.Login)
+		}
+		if !organizations.PageInfo.HasNextPage {
+			return slugs, nil
+		}
+		cursor = &organizations.PageInfo.EndCursor
+	}
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubService_GetEnterpriseOrganizations(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "acme", req.Variables["slug"])
+		if req.Variables["cursor"] == nil {
+			fmt.Fprint(w, `{"data": {"enterprise": {"organizations": {"nodes": [{"login": "org-a"}], "pageInfo": {"hasNextPage": true, "endCursor": "c1"}}}}}`)
+			return
+		}
+		assert.Equal(t, "c1", req.Variables["cursor"])
+		fmt.Fprint(w, `{"data": {"enterprise": {"organizations": {"nodes": [{"login": "org-b"}], "pageInfo": {"hasNextPage": false}}}}}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	slugs, err := gs.GetEnterpriseOrganizations("acme")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"org-a", "org-b"}, slugs)
+}
+
+func TestGithubService_GetEnterpriseOrganizationsNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"data": {"enterprise": null}, "errors": [{"message": "Could not resolve to an Enterprise with the slug of 'acme'."}]}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	_, err := gs.GetEnterpriseOrganizations("acme")
+
+	assert.ErrorContains(t, err, "Could not resolve to an Enterprise")
+}
+
+func TestGithubService_GraphqlURL(t *testing.T) {
+	gs, _ := NewGithubService("token", ServiceOptions{BaseURL: "https://github.example.com/api/v3"})
+	service := gs.(*GithubService)
+
+	u, err := service.client.BaseURL.Parse(service.graphqlURL())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://github.example.com/api/graphql", u.String())
+}
@@ -0,0 +1,160 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"sort"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// A Fix applies the compliant value of a rule to a setting of an organization or a repository through the GitHub API
+type Fix struct {
+	Organization string `json:"organization"`
+	EntityType   string `json:"entity_type"`
+	EntityId     string `json:"entity_id"`
+	Rule         string `json:"rule"`
+	// The violation fixed, describing the setting before the change
+	Before string `json:"before"`
+	// The API call applying the fix
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   any    `json:"body,omitempty"`
+	apply  func(ctx context.Context, client *github.Client) error
+}
+
+// String describes the API call of the fix
+func (f Fix) String() string {
+	call := fmt.Sprintf("%s %s", f.Method, f.Path)
+	if f.Body != nil {
+		body, _ := json.Marshal(f.Body)
+		call += " " + string(body)
+	}
+	return call
+}
+
+// IGithubFixer applies fixes. Only services backed by the GitHub API implement it.
+type IGithubFixer interface {
+	ApplyFix(fix Fix) error
+}
+
+func (g *GithubService) ApplyFix(fix Fix) error {
+	ctx, cancelFn := g.requestContext()
+	defer cancelFn()
+	return fix.apply(ctx, g.client)
+}
+
+func editOrganization(settings *github.Organization) func(org string, entityId string) Fix {
+	return func(org string, entityId string) Fix {
+		return Fix{
+			Method: "PATCH",
+			Path:   fmt.Sprintf("/orgs/%s", entityId),
+			Body:   settings,
+			apply: func(ctx context.Context, client *github.Client) error {
+				_, _, err := client.Organizations.Edit(ctx, entityId, settings)
+				return err
+			},
+		}
+	}
+}
+
+func editRepository(settings *github.Repository) func(org string, entityId string) Fix {
+	return func(org string, entityId string) Fix {
+		return Fix{
+			Method: "PATCH",
+			Path:   fmt.Sprintf("/repos/%s/%s", org, entityId),
+			Body:   settings,
+			apply: func(ctx context.Context, client *github.Client) error {
+				_, _, err := client.Repositories.Edit(ctx, org, entityId, settings)
+				return err
+			},
+		}
+	}
+}
+
+// Fixes of the rules whose compliant value can be applied without choosing between several, by rule id
+var fixes = map[string]func(org string, entityId string) Fix{
+	"dependabot_alerts_enabled_for_new_repositories":               editOrganization(&github.Organization{DependabotAlertsEnabledForNewRepos: github.Bool(true)}),
+	"dependabot_security_updates_enabled_for_new_repositories":     editOrganization(&github.Organization{DependabotSecurityUpdatesEnabledForNewRepos: github.Bool(true)}),
+	"dependency_graph_enabled_for_new_repositories":                editOrganization(&github.Organization{DependencyGraphEnabledForNewRepos: github.Bool(true)}),
+	"secret_scanning_enabled_for_new_repositories":                 editOrganization(&github.Organization{SecretScanningEnabledForNewRepos: github.Bool(true)}),
+	"secret_scanning_push_protection_enabled_for_new_repositories": editOrganization(&github.Organization{SecretScanningPushProtectionEnabledForNewRepos: github.Bool(true)}),
+	"members_can_create_public_repositories":                       editOrganization(&github.Organization{MembersCanCreatePublicRepos: github.Bool(false)}),
+	"members_can_fork_private_repositories":                        editOrganization(&github.Organization{MembersCanForkPrivateRepos: github.Bool(false)}),
+	"delete_branch_on_merge":                                       editRepository(&github.Repository{DeleteBranchOnMerge: github.Bool(true)}),
+	"secret_scanning": editRepository(&github.Repository{
+		SecurityAndAnalysis: &github.SecurityAndAnalysis{SecretScanning: &github.SecretScanning{Status: github.String("enabled")}},
+	}),
+	"secret_scanning_push_protection": editRepository(&github.Repository{
+		SecurityAndAnalysis: &github.SecurityAndAnalysis{SecretScanningPushProtection: &github.SecretScanningPushProtection{Status: github.String("enabled")}},
+	}),
+	"dependabot_security_updates": func(org string, entityId string) Fix {
+		return Fix{
+			Method: "PUT",
+			Path:   fmt.Sprintf("/repos/%s/%s/automated-security-fixes", org, entityId),
+			apply: func(ctx context.Context, client *github.Client) error {
+				_, err := client.Repositories.EnableAutomatedSecurityFixes(ctx, org, entityId)
+				return err
+			},
+		}
+	},
+}
+
+// PlanFixes returns the fixes of the violations of the reports, once per entity and rule.
+// Violations of rules without a fix are left out.
+func PlanFixes(reports []types.CheckReport, registry *types.CheckRegistry) []Fix {
+	planned := make(map[string]bool)
+	var plan []Fix
+	for _, report := range reports {
+		for _, checkError := range report.Errors {
+			for key, message := range checkError.Violations {
+				rule, ok := registry.RuleForKey(key)
+				if !ok || rule.EntityType != report.EntityType {
+					continue
+				}
+				newFix, ok := fixes[rule.Id]
+				if !ok {
+					continue
+				}
+				id := fmt.Sprintf("%s/%s/%s/%s", report.Organization, report.EntityType, report.EntityId, rule.Id)
+				if planned[id] {
+					continue
+				}
+				planned[id] = true
+
+				fix := newFix(report.Organization, report.EntityId)
+				fix.Organization = report.Organization
+				fix.EntityType = report.EntityType
+				fix.EntityId = report.EntityId
+				fix.Rule = rule.Id
+				fix.Before = message
+				plan = append(plan, fix)
+			}
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool {
+		a, b := plan[i], plan[j]
+		if a.Organization != b.Organization {
+			return a.Organization < b.Organization
+		}
+		if a.EntityType != b.EntityType {
+			return a.EntityType < b.EntityType
+		}
+		if a.EntityId != b.EntityId {
+			return a.EntityId < b.EntityId
+		}
+		return a.Rule < b.Rule
+	})
+	return plan
+}
+
+// A ChangeRecord logs the outcome of a fix applied to GitHub, along with the setting before the change
+type ChangeRecord struct {
+	Timestamp string `json:"rfc3339_timestamp"`
+	Fix
+	// One of applied or failed
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
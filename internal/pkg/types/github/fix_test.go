@@ -0,0 +1,109 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanFixes(t *testing.T) {
+	reports := []types.CheckReport{
+		{
+			EntityType:   RepositoryEntityType,
+			EntityId:     "api",
+			Organization: "acme",
+			Errors: []types.CheckError{
+				{Check: types.GoCGuardrails, Violations: map[string]string{
+					"secret_scanning":        "secret_scanning is not enabled. Expected it to be enabled",
+					"delete_branch_on_merge": "delete_branch_on_merge is not enabled. Expected it to be enabled",
+					"rulesets.pull_request":  "pull_request rule is missing",
+				}},
+				{Check: types.CISSoftwareSupplyChain, Violations: map[string]string{
+					"secret_scanning": "secret_scanning is not enabled. Expected it to be enabled",
+				}},
+			},
+		},
+		{
+			EntityType:   OrganizationEntityType,
+			EntityId:     "acme",
+			Organization: "acme",
+			Errors: []types.CheckError{
+				{Check: types.GoCGuardrails, Violations: map[string]string{
+					"members_can_create_public_repositories": "members_can_create_public_repositories is enabled. Expected it to be disabled",
+				}},
+			},
+		},
+	}
+
+	fixes := PlanFixes(reports, types.DefaultRegistry)
+
+	assert.Len(t, fixes, 3)
+	assert.Equal(t, "PATCH /orgs/acme {\"members_can_create_public_repositories\":false}", fixes[0].String())
+	assert.Equal(t, "delete_branch_on_merge", fixes[1].Rule)
+	assert.Equal(t, "delete_branch_on_merge is not enabled. Expected it to be enabled", fixes[1].Before)
+	assert.Equal(t, "PATCH /repos/acme/api {\"delete_branch_on_merge\":true}", fixes[1].String())
+	assert.Equal(t, "PATCH /repos/acme/api {\"security_and_analysis\":{\"secret_scanning\":{\"status\":\"enabled\"}}}", fixes[2].String())
+}
+
+func TestGithubService_ApplyFix(t *testing.T) {
+	var calls []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body))
+		switch r.URL.Path {
+		case "/repos/acme/legacy":
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "Must have admin rights to Repository."}`)
+		case "/repos/acme/api/automated-security-fixes":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+	reports := []types.CheckReport{
+		{EntityType: RepositoryEntityType, EntityId: "api", Organization: "acme", Errors: []types.CheckError{{Violations: map[string]string{"dependabot_security_updates": "not enabled"}}}},
+		{EntityType: RepositoryEntityType, EntityId: "legacy", Organization: "acme", Errors: []types.CheckError{{Violations: map[string]string{"delete_branch_on_merge": "not enabled"}}}},
+		{EntityType: OrganizationEntityType, EntityId: "acme", Organization: "acme", Errors: []types.CheckError{{Violations: map[string]string{"secret_scanning_enabled_for_new_repositories": "not enabled"}}}},
+	}
+
+	fixes := PlanFixes(reports, types.DefaultRegistry)
+	assert.Len(t, fixes, 3)
+	assert.NoError(t, gs.ApplyFix(fixes[0]))
+	assert.NoError(t, gs.ApplyFix(fixes[1]))
+	assert.ErrorContains(t, gs.ApplyFix(fixes[2]), "Must have admin rights")
+
+	assert.Equal(t, []string{
+		"PATCH /orgs/acme {\"secret_scanning_enabled_for_new_repositories\":true}\n",
+		"PUT /repos/acme/api/automated-security-fixes ",
+		"PATCH /repos/acme/legacy {\"delete_branch_on_merge\":true}\n",
+	}, calls)
+}
+
+func TestChangeRecord_MarshalJSON(t *testing.T) {
+	fixes := PlanFixes([]types.CheckReport{
+		{EntityType: RepositoryEntityType, EntityId: "api", Organization: "acme", Errors: []types.CheckError{{Violations: map[string]string{"delete_branch_on_merge": "not enabled"}}}},
+	}, types.DefaultRegistry)
+
+	data, err := json.Marshal(ChangeRecord{Timestamp: "2026-10-17T09:00:00Z", Fix: fixes[0], Status: "applied"})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"rfc3339_timestamp": "2026-10-17T09:00:00Z",
+		"organization": "acme",
+		"entity_type": "github_repository",
+		"entity_id": "api",
+		"rule": "delete_branch_on_merge",
+		"before": "not enabled",
+		"method": "PATCH",
+		"path": "/repos/acme/api",
+		"body": {"delete_branch_on_merge": true},
+		"status": "applied"
+	}`, string(data))
+}
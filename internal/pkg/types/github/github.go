@@ -2,67 +2,303 @@ package github
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/google/go-github/v61/github"
 )
 
 type IGithubService interface {
-	GetOrganization(slug string) (Organization, error)
-	GetRepositories(owner string, filterFn func(r Repository) bool) ([]Repository, error)
+	GetOrganization(slug string, options OrganizationOptions) (Organization, error)
+	GetRepositories(owner string, options RepositoryListOptions, filterFn func(r Repository) bool) ([]Repository, error)
+	GetEnterpriseOrganizations(enterprise string) ([]string, error)
+	GetTeams(org string) ([]Team, error)
+	GetMembers(org string, options MemberOptions) ([]Member, error)
+}
+
+// OrganizationOptions selects the optional settings fetched by GetOrganization
+type OrganizationOptions struct {
+	// Also fetch the Actions settings of the organization, at the cost of several requests
+	ActionsSettings bool
+}
+
+// MemberOptions selects the optional data fetched by GetMembers
+type MemberOptions struct {
+	// Also fetch the latest public event of every member, at the cost of one request per member
+	Activity bool
+}
+
+// RepositoryListOptions filters the repositories returned by GetRepositories
+type RepositoryListOptions struct {
+	// Type of repositories to list. One of all, public, private, forks, sources or member. Defaults to all.
+	Type string
+	// Only keep repositories with one of the given visibilities (public, private or internal). Keeps all when empty.
+	Visibilities    []string
+	ExcludeArchived bool
+	ExcludeForks    bool
+	// Also fetch the classic protection of the default branch and of the ProtectedBranches, at the cost of one request per branch
+	BranchProtection bool
+	// Branches whose protection is fetched in addition to the default branch, by repository full name (owner/name)
+	ProtectedBranches map[string][]string
+	// License templates of the repositories managed in the HCL inputs, by repository full name (owner/name)
+	LicenseTemplates map[string]string
+	// Also fetch the Actions settings of every repository, at the cost of several requests per repository
+	ActionsSettings bool
+	// Also fetch the community health files and the workflows of every repository, at the cost of several requests per repository
+	Contents bool
+	// Also list the open security alerts of every repository, at the cost of several requests per repository
+	Alerts bool
+	// Days an open security alert of a severity may stay open. Defaults to DefaultAlertMaxAges when nil.
+	AlertMaxAges map[string]int
+	// Files required in the repositories of each visibility. Defaults to DefaultHygieneRequirements when nil.
+	HygieneRequirements map[string][]string
+}
+
+func (o RepositoryListOptions) matches(r *github.Repository) bool {
+	if o.ExcludeArchived && r.GetArchived() {
+		return false
+	}
+	if o.ExcludeForks && r.GetFork() {
+		return false
+	}
+	if len(o.Visibilities) == 0 {
+		return true
+	}
+	for _, v := range o.Visibilities {
+		if r.GetVisibility() == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceOptions controls how the GithubService talks to the GitHub API
+type ServiceOptions struct {
+	// Base URL of the GitHub Enterprise Server API, e.g. https://github.example.com/api/v3. Uses api.github.com when empty.
+	BaseURL string
+	// Number of requests made in parallel when fetching per repository data
+	Concurrency int
+	// Number of times a request rejected by a rate limit or failing with a server error is retried
+	MaxRetries int
+	// Timeout of a single attempt of a request. The time spent waiting for rate limits to reset is not included.
+	RequestTimeout time.Duration
+}
+
+func DefaultServiceOptions() ServiceOptions {
+	return ServiceOptions{
+		Concurrency:    8,
+		MaxRetries:     5,
+		RequestTimeout: 5 * time.Minute,
+	}
 }
 
 type GithubService struct {
-	client *github.Client
+	client  *github.Client
+	options ServiceOptions
+}
+
+// withDefaults replaces the unset options with their default value
+func (o ServiceOptions) withDefaults() ServiceOptions {
+	defaults := DefaultServiceOptions()
+	if o.Concurrency < 1 {
+		o.Concurrency = defaults.Concurrency
+	}
+	if o.RequestTimeout <= 0 {
+		o.RequestTimeout = defaults.RequestTimeout
+	}
+	return o
+}
+
+func NewGithubService(authToken string, options ServiceOptions) (IGithubService, error) {
+	options = options.withDefaults()
+	httpClient := &http.Client{
+		Transport: newRateLimitTransport(http.DefaultTransport, options.MaxRetries, options.RequestTimeout),
+	}
+	client, err := withBaseURL(github.NewClient(httpClient), options.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	return newGithubService(client.WithAuthToken(authToken), options), nil
+}
+
+// IsDotCom reports whether the base URL targets github.com rather than a GitHub Enterprise Server
+func IsDotCom(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	return baseURL == "" || (err == nil && (u.Host == "api.github.com" || u.Host == "github.com"))
 }
 
-func NewGithubService(authToken string) IGithubService {
+// withBaseURL points the client at a GitHub Enterprise Server. The upload URL is derived from the host of the base URL.
+func withBaseURL(client *github.Client, baseURL string) (*github.Client, error) {
+	if IsDotCom(baseURL) {
+		return client, nil
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitHub API URL %q", baseURL)
+	}
+	uploadURL := fmt.Sprintf("%s://%s/api/uploads/", u.Scheme, u.Host)
+	return client.WithEnterpriseURLs(baseURL, uploadURL)
+}
+
+func newGithubService(client *github.Client, options ServiceOptions) *GithubService {
 	return &GithubService{
-		client: github.NewClient(nil).WithAuthToken(authToken),
+		client:  client,
+		options: options.withDefaults(),
+	}
+}
+
+// Every request gets its own context. It has no deadline: the transport applies the RequestTimeout to every
+// attempt of the request, so that waiting for a rate limit to reset does not make the request time out.
+func (g *GithubService) requestContext() (context.Context, context.CancelFunc) {
+	return context.WithCancel(context.Background())
+}
+
+// listAll requests every page of a list endpoint. fetch is called with the page to request, starting with the first one.
+func listAll[T any](g *GithubService, fetch func(ctx context.Context, opts github.ListOptions) ([]T, *github.Response, error)) ([]T, error) {
+	var all []T
+	opts := github.ListOptions{PerPage: 100}
+	for {
+		ctx, cancelFn := g.requestContext()
+		page, resp, err := fetch(ctx, opts)
+		cancelFn()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if resp.NextPage == 0 {
+			return all, nil
+		}
+		opts.Page = resp.NextPage
 	}
 }
 
-func (g *GithubService) GetOrganization(slug string) (Organization, error) {
-	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Minute)
+// forEach calls fn for every index in [0, count) using at most Concurrency goroutines
+func (g *GithubService) forEach(count int, fn func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(g.options.Concurrency, count); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+func (g *GithubService) GetOrganization(slug string, options OrganizationOptions) (Organization, error) {
+	ctx, cancelFn := g.requestContext()
 	defer cancelFn()
 	o, _, err := g.client.Organizations.Get(ctx, slug)
 	if err != nil {
 		return Organization{}, err
 	}
 
-	return Organization{
-		Organization: o,
-	}, nil
+	org := Organization{Organization: o}
+	if options.ActionsSettings {
+		org.actions = g.getOrganizationActions(slug)
+	}
+	// Custom repository roles need Enterprise Cloud and an owner token. Failing to list them only fails the rules reading them.
+	rolesCtx, rolesCancelFn := g.requestContext()
+	defer rolesCancelFn()
+	roles, _, err := g.client.Organizations.ListCustomRepoRoles(rolesCtx, slug)
+	if err != nil {
+		org.customRepositoryRolesError = err.Error()
+		return org, nil
+	}
+	org.customRepositoryRoles = make([]github.CustomRepoRoles, 0, len(roles.CustomRepoRoles))
+	for _, role := range roles.CustomRepoRoles {
+		org.customRepositoryRoles = append(org.customRepositoryRoles, *role)
+	}
+	return org, nil
 }
 
-func (g *GithubService) GetRepositories(owner string, filterFn func(r Repository) bool) ([]Repository, error) {
-	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Minute)
-	defer cancelFn()
+// getBranchProtections fetches the classic protection of the default branch and of the extra branches.
+// Unprotected branches get a nil protection. Errors are kept per branch so they are reported by the rules that need them.
+func (g *GithubService) getBranchProtections(owner string, repo *Repository, extraBranches []string) {
+	repo.branchProtections = make(map[string]*github.Protection)
+	for _, branch := range repo.protectedBranches(extraBranches) {
+		ctx, cancelFn := g.requestContext()
+		protection, _, err := g.client.Repositories.GetBranchProtection(ctx, owner, repo.GetName(), branch)
+		cancelFn()
+		if err != nil && !errors.Is(err, github.ErrBranchNotProtected) {
+			if repo.branchProtectionErrors == nil {
+				repo.branchProtectionErrors = make(map[string]string)
+			}
+			repo.branchProtectionErrors[branch] = err.Error()
+			continue
+		}
+		repo.branchProtections[branch] = protection
+	}
+}
 
-	repos, _, err := g.client.Repositories.ListByUser(ctx, owner, nil)
+// GetRepositories pages through every repository of the organization. Repositories not matching the options
+// or rejected by filterFn are skipped before their rulesets are fetched. filterFn may be nil.
+func (g *GithubService) GetRepositories(owner string, options RepositoryListOptions, filterFn func(r Repository) bool) ([]Repository, error) {
+	scannedAt := now()
+	all, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return g.client.Repositories.ListByOrg(ctx, owner, &github.RepositoryListByOrgOptions{Type: options.Type, ListOptions: opts})
+	})
 	if err != nil {
 		return []Repository{}, err
 	}
 
-	var repositories []Repository
+	var repos []*github.Repository
+	for _, r := range all {
+		if !options.matches(r) {
+			continue
+		}
+		if filterFn != nil && !filterFn(Repository{slug: r.GetName(), Repository: r}) {
+			continue
+		}
+		repos = append(repos, r)
+	}
 
-	for _, r := range repos {
-		rules, _, err := g.client.Repositories.GetRulesForBranch(ctx, owner, r.GetName(), r.GetDefaultBranch())
-		var rulesets []map[string]interface{}
-		if err == nil {
-			rulesetBytes, err := json.Marshal(rules)
-			if err == nil {
-				json.Unmarshal(rulesetBytes, &rulesets)
+	repositories := make([]Repository, len(repos))
+	g.forEach(len(repos), func(i int) {
+		r := repos[i]
+		repositories[i] = Repository{
+			slug:                r.GetName(),
+			licenseTemplate:     options.licenseTemplate(owner, r),
+			alertMaxAges:        options.alertMaxAges(),
+			hygieneRequirements: options.hygieneRequirements(),
+			scannedAt:           scannedAt,
+			Repository:          r,
+		}
+		// Empty repositories have no default branch and so no rules
+		if r.GetDefaultBranch() != "" {
+			ctx, cancelFn := g.requestContext()
+			rules, _, err := g.client.Repositories.GetRulesForBranch(ctx, owner, r.GetName(), r.GetDefaultBranch())
+			cancelFn()
+			if err != nil {
+				repositories[i].rulesetsError = err.Error()
 			}
+			repositories[i].rulesets = rules
 		}
-
-		repositories = append(repositories, Repository{
-			slug:       r.GetName(),
-			rulesets:   rulesets,
-			Repository: r,
-		})
-	}
+		if options.BranchProtection {
+			g.getBranchProtections(owner, &repositories[i], options.ProtectedBranches[owner+"/"+r.GetName()])
+		}
+		if options.ActionsSettings {
+			repositories[i].actions = g.getRepositoryActions(owner, r)
+		}
+		if options.Contents {
+			repositories[i].contents = g.getRepositoryContents(owner, r)
+		}
+		if options.Alerts {
+			repositories[i].alerts = g.getRepositoryAlerts(owner, r)
+		}
+	})
 
 	return repositories, nil
 }
@@ -0,0 +1,142 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGithubService(t *testing.T, mux *http.ServeMux) *GithubService {
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	transport := newRateLimitTransport(http.DefaultTransport, 3, time.Minute)
+	transport.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	client := github.NewClient(&http.Client{Transport: transport})
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return newGithubService(client, ServiceOptions{Concurrency: 4})
+}
+
+func TestGithubService_GetRepositoriesPaginates(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+		switch r.URL.Query().Get("page") {
+		case "", "1":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s/orgs/org/repos?page=2&per_page=100>; rel="next"`, r.Host))
+			fmt.Fprint(w, `[{"name": "api", "visibility": "private"}, {"name": "archived", "archived": true, "visibility": "private"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"name": "docs", "visibility": "public"}, {"name": "fork", "fork": true, "visibility": "private"}]`)
+		}
+	})
+	mux.HandleFunc("/repos/org/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 4)
+
+	repos, err = gs.GetRepositories("org", RepositoryListOptions{ExcludeArchived: true, ExcludeForks: true}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+
+	repos, err = gs.GetRepositories("org", RepositoryListOptions{Visibilities: []string{"public"}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+	assert.Equal(t, "docs", repos[0].slug)
+
+	repos, err = gs.GetRepositories("org", RepositoryListOptions{}, func(r Repository) bool { return r.GetName() == "api" })
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+	assert.Equal(t, "api", repos[0].slug)
+}
+
+func TestGithubService_GetRepositoriesError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Not Found"}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	_, err := gs.GetRepositories("org", RepositoryListOptions{}, nil)
+
+	assert.Error(t, err)
+}
+
+func TestGithubService_GetOrganizationCustomRoles(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "org"}`)
+	})
+	mux.HandleFunc("/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 1, "custom_roles": [{"name": "Contractor", "base_role": "write", "permissions": ["push_protection_bypass", "manage_webhooks"]}]}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	org, err := gs.GetOrganization("org", OrganizationOptions{})
+
+	assert.NoError(t, err)
+	roles, err := org.CustomRepositoryRoles()
+	assert.NoError(t, err)
+	assert.Len(t, roles, 1)
+	report := org.Check(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails})
+	assert.NotContains(t, report.Errors[0].Violations, "contractor_role")
+	assert.Contains(t, report.Errors[0].Violations, "security_engineer_role")
+}
+
+func TestGithubService_GetOrganizationCustomRolesError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "org"}`)
+	})
+	mux.HandleFunc("/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "Must be an organization owner"}`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	org, err := gs.GetOrganization("org", OrganizationOptions{})
+
+	// The rest of the organization is still checked
+	assert.NoError(t, err)
+	report := org.Check(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails})
+	assert.Equal(t, types.Errored, report.Results[types.GoCGuardrails])
+	assert.Contains(t, report.Errors[0].Violations, "dependabot_alerts_enabled_for_new_repositories")
+	assert.NotContains(t, report.Errors[0].Violations, "contractor_role")
+	assert.Contains(t, report.Errors[0].Unevaluated["contractor_role"], "unable to list the custom repository roles: GET")
+}
+
+func TestNewGithubService_EnterpriseURLs(t *testing.T) {
+	gs, err := NewGithubService("token", ServiceOptions{BaseURL: "https://github.example.com/api/v3"})
+
+	assert.NoError(t, err)
+	client := gs.(*GithubService).client
+	assert.Equal(t, "https://github.example.com/api/v3/", client.BaseURL.String())
+	assert.Equal(t, "https://github.example.com/api/uploads/", client.UploadURL.String())
+}
+
+func TestNewGithubService_DotCom(t *testing.T) {
+	for _, baseURL := range []string{"", "https://api.github.com"} {
+		gs, err := NewGithubService("token", ServiceOptions{BaseURL: baseURL})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "https://api.github.com/", gs.(*GithubService).client.BaseURL.String())
+	}
+}
+
+func TestNewGithubService_InvalidURL(t *testing.T) {
+	_, err := NewGithubService("token", ServiceOptions{BaseURL: "github.example.com"})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,228 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+// Files the hygiene rules can require, named after their rule without the hygiene_ prefix
+const (
+	ReadmeFile     = "readme"
+	LicenseFile    = "license"
+	CodeownersFile = "codeowners"
+)
+
+var hygieneFiles = []string{ReadmeFile, LicenseFile, CodeownersFile}
+
+var repositoryVisibilities = []string{"public", "private", "internal"}
+
+// DefaultHygieneRequirements returns the files required in the repositories of each visibility.
+// Public repositories are read by anyone so they also need a license.
+func DefaultHygieneRequirements() map[string][]string {
+	return map[string][]string{
+		"public":   {ReadmeFile, LicenseFile, CodeownersFile},
+		"private":  {ReadmeFile, CodeownersFile},
+		"internal": {ReadmeFile, CodeownersFile},
+	}
+}
+
+// LoadHygieneRequirements reads the files required by visibility from a YAML file, e.g.
+//
+//	public: [readme, license, codeowners]
+//	private: [readme]
+//
+// Visibilities missing from the file have no required file.
+func LoadHygieneRequirements(path string) (map[string][]string, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read hygiene file %q: %w", path, err)
+	}
+
+	var requirements map[string][]string
+	if err := yaml.UnmarshalStrict(data, &requirements); err != nil {
+		return nil, fmt.Errorf("unable to parse hygiene file %q: %w", path, err)
+	}
+	var errs error
+	for visibility, files := range requirements {
+		if !slices.Contains(repositoryVisibilities, visibility) {
+			errs = errors.Join(errs, fmt.Errorf("unknown visibility %q. Expected one of %s", visibility, strings.Join(repositoryVisibilities, ", ")))
+		}
+		for _, file := range files {
+			if !slices.Contains(hygieneFiles, file) {
+				errs = errors.Join(errs, fmt.Errorf("unknown file %q required for %s repositories. Expected one of %s", file, visibility, strings.Join(hygieneFiles, ", ")))
+			}
+		}
+	}
+	if errs != nil {
+		return nil, fmt.Errorf("invalid hygiene file %q: %w", path, errs)
+	}
+	return requirements, nil
+}
+
+var (
+	readmeFiles  = inCommunityDirectories("README.md", "README", "README.markdown", "README.rst", "README.txt", "README.adoc")
+	licenseFiles = []string{"LICENSE", "LICENSE.md", "LICENSE.txt", "LICENSE.rst", "COPYING", "COPYING.md", "COPYING.txt"}
+	// In the order GitHub looks for the CODEOWNERS file, the first one found being used
+	codeownersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+)
+
+// Codeowners holds the owners of the CODEOWNERS file used by GitHub and the errors GitHub found in it
+type Codeowners struct {
+	Path string `json:"path"`
+	// Owners assigned by the file, in the order they first appear
+	Owners []string `json:"owners,omitempty"`
+	// Errors of the lines of the file, such as unknown owners or owners without write access
+	Errors []string `json:"errors,omitempty"`
+}
+
+// parseCodeowners returns the owners assigned by the lines of a CODEOWNERS file, ignoring comments
+func parseCodeowners(content string) []string {
+	var owners []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, owner := range fields[1:] {
+			if !slices.Contains(owners, owner) {
+				owners = append(owners, owner)
+			}
+		}
+	}
+	return owners
+}
+
+// getCodeowners reads the CODEOWNERS file of the default branch and lists the errors GitHub found in it
+func (g *GithubService) getCodeowners(owner string, repo string, path string) (*Codeowners, error) {
+	content, err := g.getFile(owner, repo, path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancelFn := g.requestContext()
+	defer cancelFn()
+	codeownersErrors, _, err := g.client.Repositories.GetCodeownersErrors(ctx, owner, repo, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	codeowners := &Codeowners{Path: path, Owners: parseCodeowners(content)}
+	for _, e := range codeownersErrors.Errors {
+		message := strings.TrimSpace(strings.SplitN(e.Message, "\n", 2)[0])
+		codeowners.Errors = append(codeowners.Errors, fmt.Sprintf("line %d: %s", e.Line, message))
+	}
+	return codeowners, nil
+}
+
+// visibility of the repository. Older GitHub Enterprise Server versions do not return it, so it is derived from private.
+func visibility(repo *Repository) string {
+	if v := repo.GetVisibility(); v != "" {
+		return v
+	}
+	if repo.GetPrivate() {
+		return "private"
+	}
+	return "public"
+}
+
+// requiredFor reports whether the file is required in the repository given its visibility
+func requiredFor(repo *Repository, file string) bool {
+	return slices.Contains(repo.hygieneRequirements[visibility(repo)], file)
+}
+
+func evaluateLicense(repo *Repository) error {
+	if err := requireFile(repo, "license", licenseFiles); err != nil {
+		return err
+	}
+	if repo.licenseTemplate == "" {
+		return nil
+	}
+	// GitHub detects the license of the file and reports its key, e.g. mit or apache-2.0
+	if key := repo.GetLicense().GetKey(); !strings.EqualFold(key, repo.licenseTemplate) {
+		if key == "" {
+			key = "not recognized"
+		}
+		return fmt.Errorf("license is %s. Expected it to be %s", key, repo.licenseTemplate)
+	}
+	return nil
+}
+
+func evaluateCodeowners(repo *Repository) error {
+	if err := requireFile(repo, "CODEOWNERS", codeownersFiles); err != nil {
+		return err
+	}
+	path, _ := repo.contents.findFile(codeownersFiles...)
+	if message, ok := repo.contents.Errors[path]; ok {
+		return types.EvaluationErrorf("unable to read %s: %s", path, message)
+	}
+	codeowners := repo.contents.Codeowners
+	if codeowners == nil || len(codeowners.Owners) == 0 {
+		return fmt.Errorf("%s assigns no owner. Expected it to assign owners to the files of the repository", path)
+	}
+	if len(codeowners.Errors) > 0 {
+		return fmt.Errorf("%s has invalid owners: %s. Expected every owner to be a user or team with write access", path, strings.Join(codeowners.Errors, "; "))
+	}
+	return nil
+}
+
+// hygieneRule returns a rule evaluating a required file of the repositories whose visibility requires it
+func hygieneRule(file string, description string, severity types.Severity, evaluate func(repo *Repository) error) types.Rule {
+	return types.Rule{
+		Id:          "hygiene_" + file,
+		Description: description,
+		Severity:    severity,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if !requiredFor(repo, file) {
+				return nil
+			}
+			return evaluate(repo)
+		}),
+	}
+}
+
+var hygieneRules = []types.Rule{
+	hygieneRule(ReadmeFile, "The repository has a README", types.Low, func(repo *Repository) error {
+		return requireFile(repo, "README", readmeFiles)
+	}),
+	hygieneRule(LicenseFile, "The repository has a LICENSE matching the license_template of its inputs", types.Medium, evaluateLicense),
+	hygieneRule(CodeownersFile, "The repository has a CODEOWNERS file assigning valid owners", types.Medium, evaluateCodeowners),
+}
+
+// The required files of the options, or the default ones when none are set
+func (o RepositoryListOptions) hygieneRequirements() map[string][]string {
+	if o.HygieneRequirements == nil {
+		return DefaultHygieneRequirements()
+	}
+	return o.HygieneRequirements
+}
+
+// Only the repositories with a license template in their inputs have their license compared
+func (o RepositoryListOptions) licenseTemplate(owner string, r *github.Repository) string {
+	return o.LicenseTemplates[owner+"/"+r.GetName()]
+}
+
+func init() {
+	if err := types.DefaultRegistry.Register(hygieneRules...); err != nil {
+		panic(err)
+	}
+	for _, rule := range hygieneRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.RepositoryHygiene, rule.Id); err != nil {
+			panic(err)
+		}
+	}
+	// The security policy is checked by the rule of the contents, in repositories of every visibility
+	if err := types.DefaultRegistry.RegisterProfile(types.RepositoryHygiene, "security_policy"); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,131 @@
+package github
+
+import (
+	"encoding/base64"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubService_GetRepositoriesCodeowners(t *testing.T) {
+	codeowners := base64.StdEncoding.EncodeToString([]byte("# Owners\n* @acme/platform\n/docs/ @alice @acme/platform # docs\n"))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api", "default_branch": "main", "visibility": "public", "license": {"key": "mit"}}]`)
+	})
+	mux.HandleFunc("/repos/org/api/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/api/contents/":
+			fmt.Fprint(w, `[{"type": "file", "name": "README.md", "path": "README.md"}, {"type": "file", "name": "LICENSE", "path": "LICENSE"}]`)
+		case "/repos/org/api/contents/.github":
+			fmt.Fprint(w, `[{"type": "file", "name": "CODEOWNERS", "path": ".github/CODEOWNERS"}]`)
+		case "/repos/org/api/contents/.github/CODEOWNERS":
+			fmt.Fprintf(w, `{"type": "file", "name": "CODEOWNERS", "path": ".github/CODEOWNERS", "encoding": "base64", "content": %q}`, codeowners)
+		case "/repos/org/api/codeowners/errors":
+			fmt.Fprint(w, `{"errors": [{"line": 3, "kind": "Unknown owner", "message": "Unknown owner on line 3: make sure @alice exists and has write access to the repository\n\n  /docs/ @alice"}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{Contents: true, LicenseTemplates: map[string]string{"org/api": "apache-2.0"}}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+	assert.Equal(t, &Codeowners{
+		Path:   ".github/CODEOWNERS",
+		Owners: []string{"@acme/platform", "@alice"},
+		Errors: []string{"line 3: Unknown owner on line 3: make sure @alice exists and has write access to the repository"},
+	}, repos[0].contents.Codeowners)
+
+	report := repos[0].Check(types.DefaultRegistry, []types.CheckType{types.RepositoryHygiene})
+	assert.Equal(t, types.Failed, report.Results[types.RepositoryHygiene])
+	assert.Equal(t, map[string]string{
+		"hygiene_license":    "license is mit. Expected it to be apache-2.0",
+		"security_policy":    "security policy not found. Expected one of SECURITY.md, SECURITY.markdown, SECURITY.rst, SECURITY.adoc, .github/SECURITY.md, .github/SECURITY.markdown, .github/SECURITY.rst, .github/SECURITY.adoc, docs/SECURITY.md, docs/SECURITY.markdown, docs/SECURITY.rst, docs/SECURITY.adoc",
+		"hygiene_codeowners": ".github/CODEOWNERS has invalid owners: line 3: Unknown owner on line 3: make sure @alice exists and has write access to the repository. Expected every owner to be a user or team with write access",
+	}, report.Errors[0].Violations)
+}
+
+func newHygieneTestRepository(visibility string, files ...string) *Repository {
+	return &Repository{
+		contents:            &RepositoryContents{Files: files, Codeowners: &Codeowners{Path: "CODEOWNERS", Owners: []string{"@acme/platform"}}},
+		hygieneRequirements: DefaultHygieneRequirements(),
+		Repository:          &github.Repository{Name: github.String("api"), Visibility: github.String(visibility)},
+	}
+}
+
+func TestHygieneRules_Visibility(t *testing.T) {
+	checkTypes := []types.CheckType{types.RepositoryHygiene}
+
+	private := newHygieneTestRepository("private", "README.md", "CODEOWNERS", "SECURITY.md")
+	assert.Equal(t, types.Passed, private.Check(types.DefaultRegistry, checkTypes).Results[types.RepositoryHygiene])
+
+	public := newHygieneTestRepository("public", "README.md", "CODEOWNERS")
+	report := public.Check(types.DefaultRegistry, checkTypes)
+	assert.Equal(t, types.Failed, report.Results[types.RepositoryHygiene])
+	assert.Contains(t, report.Errors[0].Violations, "hygiene_license")
+	assert.Contains(t, report.Errors[0].Violations, "security_policy")
+	assert.Len(t, report.Errors[0].Violations, 2)
+
+	// Repositories without a visibility are public unless they are private
+	legacy := newHygieneTestRepository("", "README.md", "CODEOWNERS", "SECURITY.md")
+	legacy.Private = github.Bool(true)
+	assert.Equal(t, types.Passed, legacy.Check(types.DefaultRegistry, checkTypes).Results[types.RepositoryHygiene])
+}
+
+func TestEvaluateLicense(t *testing.T) {
+	repo := newHygieneTestRepository("public", "LICENSE.md")
+	assert.NoError(t, evaluateLicense(repo))
+
+	repo.licenseTemplate = "MIT"
+	assert.EqualError(t, evaluateLicense(repo), "license is not recognized. Expected it to be MIT")
+	repo.License = &github.License{Key: github.String("mit")}
+	assert.NoError(t, evaluateLicense(repo))
+
+	assert.ErrorContains(t, evaluateLicense(newHygieneTestRepository("public", "README.md")), "license not found")
+}
+
+func TestEvaluateCodeowners(t *testing.T) {
+	repo := newHygieneTestRepository("private", "docs/CODEOWNERS")
+	assert.NoError(t, evaluateCodeowners(repo))
+
+	repo.contents.Codeowners.Owners = nil
+	assert.EqualError(t, evaluateCodeowners(repo), "docs/CODEOWNERS assigns no owner. Expected it to assign owners to the files of the repository")
+
+	repo.contents.Errors = map[string]string{"docs/CODEOWNERS": "403 Forbidden"}
+	assert.EqualError(t, evaluateCodeowners(repo), "unable to read docs/CODEOWNERS: 403 Forbidden")
+
+	assert.ErrorContains(t, evaluateCodeowners(&Repository{Repository: &github.Repository{}}), "the contents of the repository were not fetched")
+}
+
+func TestParseCodeowners(t *testing.T) {
+	owners := parseCodeowners("# comment\n\n*.go @acme/backend @bob\n/build/ # no owner\ndocs/* docs@example.com @bob\n")
+
+	assert.Equal(t, []string{"@acme/backend", "@bob", "docs@example.com"}, owners)
+}
+
+func TestLoadHygieneRequirements(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/hygiene.yaml", []byte("public: [readme, license, codeowners]\nprivate: [readme]\n"), 0644)
+	afero.WriteFile(fs, "/invalid.yaml", []byte("public: [readme, changelog, security_policy]\nsecret: [readme]\n"), 0644)
+
+	requirements, err := LoadHygieneRequirements("/hygiene.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"public":  {ReadmeFile, LicenseFile, CodeownersFile},
+		"private": {ReadmeFile},
+	}, requirements)
+
+	_, err = LoadHygieneRequirements("/invalid.yaml")
+	assert.ErrorContains(t, err, `unknown file "changelog" required for public repositories`)
+	assert.ErrorContains(t, err, `unknown file "security_policy" required for public repositories`)
+	assert.ErrorContains(t, err, `unknown visibility "secret"`)
+}
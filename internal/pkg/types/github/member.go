@@ -0,0 +1,244 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+)
+
+const MemberEntityType = "github_member"
+
+// Members without any public activity for this long are dormant. The events API only returns the events of the last 90 days.
+const dormancyPeriod = 90 * 24 * time.Hour
+
+// Replaced in tests to evaluate dormancy at a fixed time
+var now = time.Now
+
+// A Member is a member or an outside collaborator of an organization
+type Member struct {
+	*github.User
+	// Role of the member in the organization. Either admin or member. Empty for outside collaborators.
+	role                string
+	outsideCollaborator bool
+	twoFactorDisabled   bool
+	// Repositories administered by an outside collaborator
+	adminRepositories []string
+	// Whether the activity of the member was fetched
+	activityFetched bool
+	// Time of the latest public event performed by the member, nil when there was none
+	lastActivity  *time.Time
+	activityError string
+	// Time the member was scanned at, or the capture time of the snapshot it was read from
+	scannedAt time.Time
+}
+
+// The time the dormancy of the member is evaluated at
+func (m *Member) evaluatedAt() time.Time {
+	if m.scannedAt.IsZero() {
+		return now()
+	}
+	return m.scannedAt
+}
+
+func (m *Member) Check(registry *types.CheckRegistry, checkTypes []types.CheckType) types.CheckReport {
+	report := types.CheckReport{
+		EntityType: MemberEntityType,
+		EntityId:   m.GetLogin(),
+		Timestamp:  time.Now().Format(time.RFC3339), // Syslog compliant timestamp
+		Results:    make(map[types.CheckType]types.CheckResult),
+		Errors:     []types.CheckError{},
+	}
+	registry.Run(m, checkTypes, &report)
+	return report
+}
+
+// GetMembers lists the members and outside collaborators of the organization. Listing their two factor
+// authentication status requires an organization owner token.
+func (g *GithubService) GetMembers(org string, options MemberOptions) ([]Member, error) {
+	scannedAt := now()
+	listMembers := func(role string, filter string) ([]*github.User, error) {
+		return listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.User, *github.Response, error) {
+			return g.client.Organizations.ListMembers(ctx, org, &github.ListMembersOptions{Role: role, Filter: filter, ListOptions: opts})
+		})
+	}
+	listOutsideCollaborators := func(filter string) ([]*github.User, error) {
+		return listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.User, *github.Response, error) {
+			return g.client.Organizations.ListOutsideCollaborators(ctx, org, &github.ListOutsideCollaboratorsOptions{Filter: filter, ListOptions: opts})
+		})
+	}
+
+	admins, err := listMembers("admin", "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the members of %q: %w", org, err)
+	}
+	members, err := listMembers("member", "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the members of %q: %w", org, err)
+	}
+	membersWithout2FA, err := listMembers("", "2fa_disabled")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the members of %q without two factor authentication: %w", org, err)
+	}
+	collaborators, err := listOutsideCollaborators("")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the outside collaborators of %q: %w", org, err)
+	}
+	collaboratorsWithout2FA, err := listOutsideCollaborators("2fa_disabled")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the outside collaborators of %q without two factor authentication: %w", org, err)
+	}
+	adminRepositories, err := g.outsideCollaboratorAdminRepositories(org)
+	if err != nil {
+		return nil, err
+	}
+
+	without2FA := make(map[string]bool)
+	for _, u := range append(membersWithout2FA, collaboratorsWithout2FA...) {
+		without2FA[u.GetLogin()] = true
+	}
+
+	var result []Member
+	for _, u := range admins {
+		result = append(result, Member{User: u, role: "admin"})
+	}
+	for _, u := range members {
+		result = append(result, Member{User: u, role: "member"})
+	}
+	for _, u := range collaborators {
+		result = append(result, Member{User: u, outsideCollaborator: true, adminRepositories: adminRepositories[u.GetLogin()]})
+	}
+	for i := range result {
+		result[i].twoFactorDisabled = without2FA[result[i].GetLogin()]
+		result[i].scannedAt = scannedAt
+	}
+
+	if !options.Activity {
+		return result, nil
+	}
+	g.forEach(len(result), func(i int) {
+		result[i].activityFetched = true
+		ctx, cancelFn := g.requestContext()
+		defer cancelFn()
+		events, _, err := g.client.Activity.ListEventsPerformedByUser(ctx, result[i].GetLogin(), false, &github.ListOptions{PerPage: 1})
+		if err != nil {
+			result[i].activityError = err.Error()
+			return
+		}
+		if len(events) > 0 {
+			createdAt := events[0].GetCreatedAt().Time
+			result[i].lastActivity = &createdAt
+		}
+	})
+	return result, nil
+}
+
+// outsideCollaboratorAdminRepositories returns the repositories administered by each outside collaborator
+func (g *GithubService) outsideCollaboratorAdminRepositories(org string) (map[string][]string, error) {
+	repos, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+		return g.client.Repositories.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{ListOptions: opts})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get repositories of %q: %w", org, err)
+	}
+
+	adminRepositories := make(map[string][]string)
+	var mu sync.Mutex
+	var errs error
+	g.forEach(len(repos), func(i int) {
+		name := repos[i].GetName()
+		collaborators, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.User, *github.Response, error) {
+			return g.client.Repositories.ListCollaborators(ctx, org, name, &github.ListCollaboratorsOptions{Affiliation: "outside", ListOptions: opts})
+		})
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("unable to list the outside collaborators of %q: %w", name, err))
+			return
+		}
+		for _, c := range collaborators {
+			if c.GetPermissions()["admin"] {
+				adminRepositories[c.GetLogin()] = append(adminRepositories[c.GetLogin()], name)
+			}
+		}
+	})
+	return adminRepositories, errs
+}
+
+var memberRules = []types.Rule{
+	{
+		Id:          "member_two_factor_authentication",
+		Description: "Members and outside collaborators have two factor authentication enabled",
+		Severity:    types.High,
+		EntityType:  MemberEntityType,
+		Evaluate: types.EvaluateAs(func(member *Member) error {
+			if member.twoFactorDisabled {
+				return errors.New("two factor authentication is not enabled. Expected it to be enabled")
+			}
+			return nil
+		}),
+	},
+	{
+		Id:          "outside_collaborator_admin",
+		Description: "Outside collaborators do not administer repositories",
+		Severity:    types.High,
+		EntityType:  MemberEntityType,
+		Evaluate: types.EvaluateAs(func(member *Member) error {
+			if member.outsideCollaborator && len(member.adminRepositories) > 0 {
+				return fmt.Errorf("outside collaborator has admin access to %s. Expected it to have no admin access", strings.Join(member.adminRepositories, ", "))
+			}
+			return nil
+		}),
+	},
+}
+
+// The rules reading the activity of members
+var memberActivityRules = []types.Rule{
+	{
+		Id:          "member_dormant",
+		Description: "Members performed a public event in the last 90 days",
+		Severity:    types.Low,
+		EntityType:  MemberEntityType,
+		Evaluate: types.EvaluateAs(func(member *Member) error {
+			if member.outsideCollaborator {
+				return nil
+			}
+			if !member.activityFetched {
+				return types.EvaluationErrorf("the activity of the member was not fetched")
+			}
+			if member.activityError != "" {
+				return types.EvaluationErrorf("unable to read the activity of the member: %s", member.activityError)
+			}
+			if member.lastActivity == nil || member.evaluatedAt().Sub(*member.lastActivity) > dormancyPeriod {
+				return errors.New("member has no public activity in the last 90 days. Expected members to be active")
+			}
+			return nil
+		}),
+	},
+}
+
+// NeedsMemberActivity reports whether the check types run a rule reading the activity of members.
+// Fetching it costs one request per member so it is only fetched when needed.
+func NeedsMemberActivity(registry *types.CheckRegistry, checkTypes []types.CheckType) bool {
+	return selectsRule(registry, checkTypes, MemberEntityType, memberActivityRules)
+}
+
+func init() {
+	// The events API only shows the public events of a member, so members working in private repositories
+	// look dormant. The activity rules are only run when selected explicitly.
+	if err := types.DefaultRegistry.Register(append(append([]types.Rule{}, memberRules...), memberActivityRules...)...); err != nil {
+		panic(err)
+	}
+	for _, rule := range memberRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.Membership, rule.Id); err != nil {
+			panic(err)
+		}
+	}
+	registerControls(types.CISSoftwareSupplyChain, "member_two_factor_authentication", cisControls("1.3.5")...)
+}
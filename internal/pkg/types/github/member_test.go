@@ -0,0 +1,115 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGithubService_GetMembers(t *testing.T) {
+	now = func() time.Time { return time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/members", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("filter") == "2fa_disabled":
+			fmt.Fprint(w, `[{"login": "bob"}]`)
+		case r.URL.Query().Get("role") == "admin":
+			fmt.Fprint(w, `[{"login": "alice"}]`)
+		default:
+			fmt.Fprint(w, `[{"login": "bob"}]`)
+		}
+	})
+	mux.HandleFunc("/orgs/org/outside_collaborators", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") == "2fa_disabled" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"login": "carol"}]`)
+	})
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api"}]`)
+	})
+	mux.HandleFunc("/repos/org/api/collaborators", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "outside", r.URL.Query().Get("affiliation"))
+		fmt.Fprint(w, `[{"login": "carol", "permissions": {"admin": true}}]`)
+	})
+	mux.HandleFunc("/users/alice/events", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"type": "PushEvent", "created_at": "2026-05-20T00:00:00Z"}]`)
+	})
+	mux.HandleFunc("/users/bob/events", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"type": "PushEvent", "created_at": "2026-01-01T00:00:00Z"}]`)
+	})
+	mux.HandleFunc("/users/carol/events", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	members, err := gs.GetMembers("org", MemberOptions{Activity: true})
+	assert.NoError(t, err)
+	assert.Len(t, members, 3)
+
+	checkTypes := []types.CheckType{types.Membership}
+	alice := members[0].Check(types.DefaultRegistry, checkTypes)
+	assert.Equal(t, MemberEntityType, alice.EntityType)
+	assert.Equal(t, types.Passed, alice.Results[types.Membership])
+
+	bob := members[1].Check(types.DefaultRegistry, checkTypes)
+	assert.Equal(t, map[string]string{
+		"member_two_factor_authentication": "two factor authentication is not enabled. Expected it to be enabled",
+	}, bob.Errors[0].Violations)
+
+	dormant, ok := types.DefaultRegistry.RuleForKey("member_dormant")
+	assert.True(t, ok)
+	assert.EqualError(t, dormant.Evaluate(&members[1]), "member has no public activity in the last 90 days. Expected members to be active")
+
+	carol := members[2].Check(types.DefaultRegistry, checkTypes)
+	assert.Equal(t, map[string]string{
+		"outside_collaborator_admin": "outside collaborator has admin access to api. Expected it to have no admin access",
+	}, carol.Errors[0].Violations)
+}
+
+func TestGithubService_GetMembersWithoutActivity(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/members", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") == "2fa_disabled" || r.URL.Query().Get("role") == "admin" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"login": "alice"}]`)
+	})
+	mux.HandleFunc("/orgs/org/outside_collaborators", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/users/alice/events", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the activity of the member should not be fetched")
+		fmt.Fprint(w, `[]`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	members, err := gs.GetMembers("org", MemberOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+
+	dormant, ok := types.DefaultRegistry.RuleForKey("member_dormant")
+	assert.True(t, ok)
+	err = dormant.Evaluate(&members[0])
+	var evalErr *types.EvaluationError
+	assert.ErrorAs(t, err, &evalErr)
+}
+
+func TestNeedsMemberActivity(t *testing.T) {
+	assert.False(t, NeedsMemberActivity(types.DefaultRegistry, []types.CheckType{types.Membership}))
+	assert.False(t, NeedsMemberActivity(types.DefaultRegistry, []types.CheckType{types.CISSoftwareSupplyChain}))
+	registry := types.DefaultRegistry.Clone()
+	assert.NoError(t, registry.RegisterProfile(types.CustomRules, "member_dormant"))
+	assert.True(t, NeedsMemberActivity(registry, []types.CheckType{types.CustomRules}))
+}
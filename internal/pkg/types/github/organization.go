@@ -9,165 +9,236 @@ import (
 	"github.com/google/go-github/v61/github"
 )
 
+const OrganizationEntityType = "github_organization"
+
 type Organization struct {
 	*github.Organization
 	customRepositoryRoles []github.CustomRepoRoles
+	// Error listing the custom repository roles, e.g. for organizations without Enterprise Cloud
+	customRepositoryRolesError string
+	actions                    *OrganizationActions
 }
 
-func (o *Organization) Check(checkTypes []types.CheckType) types.CheckReport {
+func (o *Organization) Check(registry *types.CheckRegistry, checkTypes []types.CheckType) types.CheckReport {
 	report := types.CheckReport{
-		EntityType: "github_organization",
+		EntityType: OrganizationEntityType,
 		EntityId:   o.GetLogin(),
 		Timestamp:  time.Now().Format(time.RFC3339), // Syslog compliant timestamp
 		Results:    make(map[types.CheckType]types.CheckResult),
 		Errors:     []types.CheckError{},
 	}
-	for _, t := range checkTypes {
-		switch t {
-		case types.GoCGuardrails:
-			r, err := o.GoCGuardrailsCompliant()
-			if err != nil {
-				report.Errors = append(report.Errors, *err)
-			}
-			report.Results[t] = r
-		default:
-			report.Results[t] = types.NotApplicable
-		}
-	}
+	registry.Run(o, checkTypes, &report)
 	return report
 }
 
-// Custom repository roles for an organization need to be accessed separately from settings
-func (o *Organization) GoCGuardrailsCompliant() (types.CheckResult, *types.CheckError) {
-	var allErrors error
-	violations := make(map[string]string)
-	checks := []func(org *Organization) (string, error){
-		func(org *Organization) (string, error) {
-			key := "dependabot_alerts_enabled_for_new_repositories"
+// CustomRepositoryRoles returns the custom repository roles of the organization, or an EvaluationError when they could not be listed
+func (o *Organization) CustomRepositoryRoles() ([]github.CustomRepoRoles, error) {
+	if o.customRepositoryRolesError != "" {
+		return nil, types.EvaluationErrorf("unable to list the custom repository roles: %s", o.customRepositoryRolesError)
+	}
+	return o.customRepositoryRoles, nil
+}
+
+var organizationRules = []types.Rule{
+	{
+		Id:          "dependabot_alerts_enabled_for_new_repositories",
+		Description: "Dependabot alerts are enabled for new repositories",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetDependabotAlertsEnabledForNewRepos() {
-				return key, errors.New("dependabot_alerts_enabled_for_new_repositories is not enabled. Expected it to be enabled")
+				return errors.New("dependabot_alerts_enabled_for_new_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "dependabot_security_updates_enabled_for_new_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "dependabot_security_updates_enabled_for_new_repositories",
+		Description: "Dependabot security updates are enabled for new repositories",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetDependabotSecurityUpdatesEnabledForNewRepos() {
-				return key, errors.New("dependabot_security_updates_enabled_for_new_repositories is not enabled. Expected it to be enabled")
+				return errors.New("dependabot_security_updates_enabled_for_new_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "dependency_graph_enabled_for_new_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "dependency_graph_enabled_for_new_repositories",
+		Description: "The dependency graph is enabled for new repositories",
+		Severity:    types.Medium,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetDependencyGraphEnabledForNewRepos() {
-				return key, errors.New("dependency_graph_enabled_for_new_repositories is not enabled. Expected it to be enabled")
+				return errors.New("dependency_graph_enabled_for_new_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "security_and_analysis_enabled_for_new_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "secret_scanning_enabled_for_new_repositories",
+		Description: "Secret scanning is enabled for new repositories",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetSecretScanningEnabledForNewRepos() {
-				return key, errors.New("secret_scanning_enabled_for_new_repositories is not enabled. Expected it to be enabled")
+				return errors.New("secret_scanning_enabled_for_new_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "secret_scanning_push_protection_enabled_for_new_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "secret_scanning_push_protection_enabled_for_new_repositories",
+		Description: "Secret scanning push protection is enabled for new repositories",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetSecretScanningPushProtectionEnabledForNewRepos() {
-				return key, errors.New("secret_scanning_push_protection_enabled_for_new_repositories is not enabled. Expected it to be enabled")
+				return errors.New("secret_scanning_push_protection_enabled_for_new_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "delete_branch_on_merge"
+			return nil
+		}),
+	},
+	{
+		Id:          "members_can_create_public_repositories",
+		Description: "Members cannot create public repositories",
+		Severity:    types.High,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if org.GetMembersCanCreatePublicRepos() {
-				return key, errors.New("members_can_create_public_repositories is enabled. Expected it to be disabled")
+				return errors.New("members_can_create_public_repositories is enabled. Expected it to be disabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "members_can_create_private_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "members_can_create_private_repositories",
+		Description: "Members can create private repositories",
+		Severity:    types.Low,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetMembersCanCreatePrivateRepos() {
-				return key, errors.New("members_can_create_private_repositories is not enabled. Expected it to be enabled")
+				return errors.New("members_can_create_private_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "members_can_create_internal_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "members_can_create_internal_repositories",
+		Description: "Members can create internal repositories",
+		Severity:    types.Low,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if !org.GetMembersCanCreateInternalRepos() {
-				return key, errors.New("members_can_create_internal_repositories is not enabled. Expected it to be enabled")
+				return errors.New("members_can_create_internal_repositories is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "members_can_fork_private_repositories"
+			return nil
+		}),
+	},
+	{
+		Id:          "members_can_fork_private_repositories",
+		Description: "Members cannot fork private repositories",
+		Severity:    types.Medium,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
 			if org.GetMembersCanForkPrivateRepos() {
-				return key, errors.New("members_can_fork_private_repositories is enabled. Expected it to be disabled")
+				return errors.New("members_can_fork_private_repositories is enabled. Expected it to be disabled")
+			}
+			return nil
+		}),
+	},
+	{
+		Id:          "security_engineer_role",
+		Description: "A security engineer custom repository role is defined",
+		Severity:    types.Medium,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			roles, err := org.CustomRepositoryRoles()
+			if err != nil {
+				return err
 			}
-			return key, nil
-		},
-		func(org *Organization) (string, error) {
-			key := "security_engineer_role"
-			for _, role := range org.customRepositoryRoles {
+			for _, role := range roles {
 				base := role.GetBaseRole()
-				_, deleteCodeScanningPerm := slices.BinarySearch(role.Permissions, "delete_alerts_code_scanning")
-				_, writeCodeScanningPerm := slices.BinarySearch(role.Permissions, "write_code_scanning")
-				if base == "maintain" && deleteCodeScanningPerm && writeCodeScanningPerm {
-					return key, nil
+				if base == "maintain" && hasPermissions(role, "delete_alerts_code_scanning", "write_code_scanning") {
+					return nil
 				}
 			}
-			return key, errors.New("security engineer role undefined in the organization")
-		},
-		func(org *Organization) (string, error) {
-			key := "contractor_role"
-			for _, role := range org.customRepositoryRoles {
+			return errors.New("security engineer role undefined in the organization")
+		}),
+	},
+	{
+		Id:          "contractor_role",
+		Description: "A contractor custom repository role is defined",
+		Severity:    types.Medium,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			roles, err := org.CustomRepositoryRoles()
+			if err != nil {
+				return err
+			}
+			for _, role := range roles {
 				base := role.GetBaseRole()
-				_, manageWebhhooksPerm := slices.BinarySearch(role.Permissions, "manage_webhooks")
-				if base == "write" && manageWebhhooksPerm {
-					return key, nil
+				if base == "write" && hasPermissions(role, "manage_webhooks") {
+					return nil
 				}
 			}
-			return key, errors.New("contractor role undefined in the organization")
-		},
-
-		func(org *Organization) (string, error) {
-			key := "community_manager_role"
-			for _, role := range org.customRepositoryRoles {
+			return errors.New("contractor role undefined in the organization")
+		}),
+	},
+	{
+		Id:          "community_manager_role",
+		Description: "A community manager custom repository role is defined",
+		Severity:    types.Medium,
+		EntityType:  OrganizationEntityType,
+		Evaluate: types.EvaluateAs(func(org *Organization) error {
+			roles, err := org.CustomRepositoryRoles()
+			if err != nil {
+				return err
+			}
+			for _, role := range roles {
 				base := role.GetBaseRole()
-				_, markAsDuplicatePerm := slices.BinarySearch(role.Permissions, "mark_as_duplicate")
-				_, manageSettingsPagePerm := slices.BinarySearch(role.Permissions, "manage_settings_pages")
-				_, manageSettingsWikiPerm := slices.BinarySearch(role.Permissions, "manage_settings_wiki")
-				_, setSocialPreviewPrem := slices.BinarySearch(role.Permissions, "set_social_preview")
-				_, editRepoMetdataPerm := slices.BinarySearch(role.Permissions, "edit_repo_metadata")
-				_, editDiscussionCategoryPerm := slices.BinarySearch(role.Permissions, "edit_discussion_category")
-				_, createDiscussionCategoryPerm := slices.BinarySearch(role.Permissions, "create_discussion_category")
-				_, editCategoryOnDiscussionPerm := slices.BinarySearch(role.Permissions, "edit_category_on_discussion")
-				_, toggleDiscussionAnswerPerm := slices.BinarySearch(role.Permissions, "toggle_discussion_answer")
-				_, convertIssuesToDiscussionsPerm := slices.BinarySearch(role.Permissions, "convert_issues_to_discussions")
-				_, closeDiscussionPerm := slices.BinarySearch(role.Permissions, "close_discussion")
-				_, reopenDiscussionPerm := slices.BinarySearch(role.Permissions, "reopen_discussion")
-				_, deleteDiscussionCommentPerm := slices.BinarySearch(role.Permissions, "delete_discussion_comment")
-				if base == "read" && markAsDuplicatePerm && manageSettingsPagePerm && manageSettingsWikiPerm && setSocialPreviewPrem && editRepoMetdataPerm && editDiscussionCategoryPerm && createDiscussionCategoryPerm && editCategoryOnDiscussionPerm && toggleDiscussionAnswerPerm && convertIssuesToDiscussionsPerm && closeDiscussionPerm && reopenDiscussionPerm && deleteDiscussionCommentPerm {
-					return key, nil
+				if base == "read" && hasPermissions(role,
+					"mark_as_duplicate",
+					"manage_settings_pages",
+					"manage_settings_wiki",
+					"set_social_preview",
+					"edit_repo_metadata",
+					"edit_discussion_category",
+					"create_discussion_category",
+					"edit_category_on_discussion",
+					"toggle_discussion_answer",
+					"convert_issues_to_discussions",
+					"close_discussion",
+					"reopen_discussion",
+					"delete_discussion_comment",
+				) {
+					return nil
 				}
 			}
-			return key, errors.New("community manager role undefined in the organization")
-		},
-	}
+			return errors.New("community manager role undefined in the organization")
+		}),
+	},
+}
 
-	for _, check := range checks {
-		violationKey, err := check(o)
-		if err != nil {
-			violations[violationKey] = err.Error()
+// hasPermissions reports whether the role grants every permission. The API does not sort permissions.
+func hasPermissions(role github.CustomRepoRoles, permissions ...string) bool {
+	for _, p := range permissions {
+		if !slices.Contains(role.Permissions, p) {
+			return false
 		}
-		allErrors = errors.Join(allErrors, err)
 	}
+	return true
+}
 
-	if allErrors != nil {
-		return types.Failed, &types.CheckError{
-			Err:        allErrors,
-			Check:      types.GoCGuardrails,
-			Violations: violations,
+func init() {
+	if err := types.DefaultRegistry.Register(organizationRules...); err != nil {
+		panic(err)
+	}
+	for _, rule := range organizationRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.GoCGuardrails, rule.Id); err != nil {
+			panic(err)
 		}
 	}
-	return types.Passed, nil
+	registerControls(types.CISSoftwareSupplyChain, "members_can_create_public_repositories", cisControls("1.2.2")...)
 }
@@ -2,118 +2,147 @@ package github
 
 import (
 	"errors"
-	"fmt"
 	"gh_foundations/internal/pkg/types"
-	"reflect"
 	"time"
 
 	"github.com/google/go-github/v61/github"
 )
 
+const RepositoryEntityType = "github_repository"
+
 type Repository struct {
 	slug     string
-	rulesets []map[string]any
+	// Rules of the repository and organization rulesets active on the default branch
+	rulesets []*github.RepositoryRule
+	// Error fetching the rules of the default branch
+	rulesetsError string
+	// Classic protection of the default branch and of the branches protected in the HCL inputs, by branch, only
+	// fetched when a selected rule reads it. Unprotected branches have a nil protection.
+	branchProtections map[string]*github.Protection
+	// Errors fetching the protection of a branch, by branch
+	branchProtectionErrors map[string]string
+	// Actions settings, only fetched when a selected rule reads them
+	actions *RepositoryActions
+	// Files of the default branch, only fetched when a selected rule reads them
+	contents *RepositoryContents
+	// Open security alerts, only fetched when a selected rule reads them
+	alerts *RepositoryAlerts
+	// License template of the HCL inputs of the repository, if it is managed there
+	licenseTemplate string
+	// Days an open security alert of a severity may stay open
+	alertMaxAges map[string]int
+	// Files required in the repositories of each visibility
+	hygieneRequirements map[string][]string
+	// Time the repository was scanned at, or the capture time of the snapshot it was read from
+	scannedAt time.Time
 	*github.Repository
 }
 
-func (r *Repository) Check(checkTypes []types.CheckType) types.CheckReport {
+// ScannedAt returns the time the ages of the alerts of the repository are measured at:
+// the start of the scan, or the capture time of the snapshot the repository was read from
+func (r *Repository) ScannedAt() time.Time {
+	if r.scannedAt.IsZero() {
+		return now()
+	}
+	return r.scannedAt
+}
+
+func (r *Repository) Check(registry *types.CheckRegistry, checkTypes []types.CheckType) types.CheckReport {
 	report := types.CheckReport{
-		EntityType: "github_repository",
+		EntityType: RepositoryEntityType,
 		EntityId:   r.slug,
-		Timestamp:	time.Now().Format(time.RFC3339), // Syslog compliant timestamp
+		Timestamp:  time.Now().Format(time.RFC3339), // Syslog compliant timestamp
 		Results:    make(map[types.CheckType]types.CheckResult),
 		Errors:     []types.CheckError{},
 	}
-	for _, t := range checkTypes {
-		switch t {
-		case types.GoCGuardrails:
-			r, err := r.GoCGuardrailsCompliant()
-			if err != nil {
-				report.Errors = append(report.Errors, *err)
-			}
-			report.Results[t] = r
-		default:
-			report.Results[t] = types.NotApplicable
-		}
-	}
+	registry.Run(r, checkTypes, &report)
 	return report
 }
 
-func (r *Repository) GoCGuardrailsCompliant() (types.CheckResult, *types.CheckError) {
-	var allErrors error
-	violations := make(map[string]string)
-	checks := []func(repo *Repository) (string, error) {
-		func(repo *Repository) (string, error) {
-			key := "dependabot_security_updates"
+// Rules the default branch of every repository must at least enforce, from repository or organization rulesets
+var defaultBranchRulesetRequirement = RulesetRequirement{
+	PullRequest: &github.PullRequestRuleParameters{
+		RequiredApprovingReviewCount: 1,
+		DismissStaleReviewsOnPush:    true,
+		RequireLastPushApproval:      true,
+	},
+}
+
+var repositoryRules = []types.Rule{
+	{
+		Id:          "dependabot_security_updates",
+		Description: "Dependabot security updates are enabled",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
 			if repo.GetSecurityAndAnalysis().GetDependabotSecurityUpdates().GetStatus() != "enabled" {
-				return key, errors.New("dependabot_security_updates is not enabled. Expected it to be enabled")
+				return errors.New("dependabot_security_updates is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(repo *Repository) (string, error) {
-			key := "secret_scanning"
+			return nil
+		}),
+	},
+	{
+		Id:          "secret_scanning",
+		Description: "Secret scanning is enabled",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
 			if repo.GetSecurityAndAnalysis().GetSecretScanning().GetStatus() != "enabled" {
-				return key, errors.New("secret_scanning is not enabled. Expected it to be enabled")
+				return errors.New("secret_scanning is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(repo *Repository) (string, error) {
-			key := "secret_scanning_push_protection"
+			return nil
+		}),
+	},
+	{
+		Id:          "secret_scanning_push_protection",
+		Description: "Secret scanning push protection is enabled",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
 			if repo.GetSecurityAndAnalysis().GetSecretScanningPushProtection().GetStatus() != "enabled" {
-				return key, errors.New("secret_scanning_push_protection is not enabled. Expected it to be enabled")
+				return errors.New("secret_scanning_push_protection is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(repo *Repository) (string, error) {
-			key := "delete_branch_on_merge"
+			return nil
+		}),
+	},
+	{
+		Id:          "delete_branch_on_merge",
+		Description: "Head branches are deleted automatically after a pull request is merged",
+		Severity:    types.Low,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
 			if !repo.GetDeleteBranchOnMerge() {
-				return key, errors.New("delete_branch_on_merge is not enabled. Expected it to be enabled")
+				return errors.New("delete_branch_on_merge is not enabled. Expected it to be enabled")
 			}
-			return key, nil
-		},
-		func(repo *Repository) (string, error) {
-			key := "rulesets"
-			var errs error
-			expectedRulesets := []map[string]any{
-				{
-					"type": "pull_request",
-					"parameters": map[string]any{
-						"required_approving_review_count":   1,
-						"dismiss_stale_reviews_on_push":     true,
-						"require_code_owner_review":         false,
-						"require_last_push_approval":        true,
-						"required_review_thread_resolution": false,
-					},
-					"ruleset_source_type": "Repository",
-				},
+			return nil
+		}),
+	},
+	{
+		Id:          "rulesets",
+		Description: "The default branch is protected by a pull request ruleset",
+		Severity:    types.High,
+		EntityType:  RepositoryEntityType,
+		Evaluate: types.EvaluateAs(func(repo *Repository) error {
+			if repo.rulesetsError != "" {
+				return types.EvaluationErrorf("unable to read the rules of the default branch: %s", repo.rulesetsError)
 			}
-
-			for _, ruleset := range expectedRulesets {
-				eq := reflect.DeepEqual(ruleset, r.rulesets)
-				if !eq {
-					errMsg := fmt.Sprintf("expected ruleset %q to be %q. Got %q instead", ruleset, fmt.Sprint(ruleset), fmt.Sprint(r.rulesets))
-					violations["rulesets"] = errMsg
-					errs = errors.Join(errs, errors.New(errMsg))
-				}
+			if violations := defaultBranchRulesetRequirement.Evaluate(repo.rulesets); len(violations) > 0 {
+				return violations
 			}
-			return key, errs
-		},
-	}
+			return nil
+		}),
+	},
+}
 
-	for _, check := range checks {
-		violationKey, err := check(r)
-		if err != nil {
-			violations[violationKey] = err.Error()
-		}
-		allErrors = errors.Join(allErrors, err)
+func init() {
+	if err := types.DefaultRegistry.Register(repositoryRules...); err != nil {
+		panic(err)
 	}
-
-	if allErrors != nil {
-		return types.Failed, &types.CheckError{
-			Err:        allErrors,
-			Check:      types.GoCGuardrails,
-			Violations: violations,
+	for _, rule := range repositoryRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.GoCGuardrails, rule.Id); err != nil {
+			panic(err)
 		}
 	}
-	return types.Passed, nil
+	registerControls(types.OpenSSFScorecard, "rulesets", scorecardCheck("Branch-Protection"))
+	registerControls(types.CISSoftwareSupplyChain, "secret_scanning", cisControls("1.5.1")...)
 }
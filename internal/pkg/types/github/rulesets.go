@@ -0,0 +1,119 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// Sources of the rules active on a branch
+const (
+	RepositoryRulesetSource   = "Repository"
+	OrganizationRulesetSource = "Organization"
+)
+
+// A RulesetRequirement describes the rules that must at least be enforced on a branch. Rules of every ruleset
+// targeting the branch apply together, so a requirement is met by the strictest value found across them.
+type RulesetRequirement struct {
+	// Sources whose rules are taken into account. Rules from every source are considered when empty.
+	Sources []string
+	// Types of rules without parameters that must be active, e.g. deletion or non_fast_forward
+	RuleTypes []string
+	// Minimum pull request parameters. Boolean parameters set to false are not required.
+	PullRequest *github.PullRequestRuleParameters
+	// Contexts of the status checks that must be required
+	RequiredStatusChecks []string
+}
+
+// Evaluate reports every rule type, pull request parameter and status check that is missing or weaker than required
+func (req *RulesetRequirement) Evaluate(rules []*github.RepositoryRule) types.Violations {
+	violations := make(types.Violations)
+	active := make(map[string][]*github.RepositoryRule)
+	for _, rule := range rules {
+		if len(req.Sources) > 0 && !slices.Contains(req.Sources, rule.RulesetSourceType) {
+			continue
+		}
+		active[rule.Type] = append(active[rule.Type], rule)
+	}
+
+	for _, ruleType := range req.RuleTypes {
+		if len(active[ruleType]) == 0 {
+			violations[ruleType] = fmt.Sprintf("%s rule is not enforced. Expected it to be enforced", ruleType)
+		}
+	}
+
+	if req.PullRequest != nil {
+		req.evaluatePullRequest(active["pull_request"], violations)
+	}
+
+	if len(req.RequiredStatusChecks) > 0 {
+		var contexts []string
+		for _, rule := range active["required_status_checks"] {
+			var params github.RequiredStatusChecksRuleParameters
+			if decodeParameters(rule, &params) {
+				for _, check := range params.RequiredStatusChecks {
+					contexts = append(contexts, check.Context)
+				}
+			}
+		}
+		var missing []string
+		for _, context := range req.RequiredStatusChecks {
+			if !slices.Contains(contexts, context) {
+				missing = append(missing, context)
+			}
+		}
+		if len(missing) > 0 {
+			violations["required_status_checks"] = fmt.Sprintf("status checks %s are not required. Expected them to be required", strings.Join(missing, ", "))
+		}
+	}
+
+	return violations
+}
+
+func (req *RulesetRequirement) evaluatePullRequest(rules []*github.RepositoryRule, violations types.Violations) {
+	if len(rules) == 0 {
+		violations["pull_request"] = "pull_request rule is not enforced. Expected pull requests to be required"
+		return
+	}
+
+	var effective github.PullRequestRuleParameters
+	for _, rule := range rules {
+		var params github.PullRequestRuleParameters
+		if !decodeParameters(rule, &params) {
+			continue
+		}
+		effective.RequiredApprovingReviewCount = max(effective.RequiredApprovingReviewCount, params.RequiredApprovingReviewCount)
+		effective.DismissStaleReviewsOnPush = effective.DismissStaleReviewsOnPush || params.DismissStaleReviewsOnPush
+		effective.RequireCodeOwnerReview = effective.RequireCodeOwnerReview || params.RequireCodeOwnerReview
+		effective.RequireLastPushApproval = effective.RequireLastPushApproval || params.RequireLastPushApproval
+		effective.RequiredReviewThreadResolution = effective.RequiredReviewThreadResolution || params.RequiredReviewThreadResolution
+	}
+
+	expected := req.PullRequest
+	if effective.RequiredApprovingReviewCount < expected.RequiredApprovingReviewCount {
+		violations["required_approving_review_count"] = fmt.Sprintf("required_approving_review_count is %d. Expected it to be at least %d",
+			effective.RequiredApprovingReviewCount, expected.RequiredApprovingReviewCount)
+	}
+	requireEnabled(violations, "dismiss_stale_reviews_on_push", expected.DismissStaleReviewsOnPush, effective.DismissStaleReviewsOnPush)
+	requireEnabled(violations, "require_code_owner_review", expected.RequireCodeOwnerReview, effective.RequireCodeOwnerReview)
+	requireEnabled(violations, "require_last_push_approval", expected.RequireLastPushApproval, effective.RequireLastPushApproval)
+	requireEnabled(violations, "required_review_thread_resolution", expected.RequiredReviewThreadResolution, effective.RequiredReviewThreadResolution)
+}
+
+func requireEnabled(violations types.Violations, parameter string, expected bool, actual bool) {
+	if expected && !actual {
+		violations[parameter] = fmt.Sprintf("%s is not enabled. Expected it to be enabled", parameter)
+	}
+}
+
+// decodeParameters unmarshals the parameters of a rule. Rules without parameters are left undecoded.
+func decodeParameters(rule *github.RepositoryRule, params any) bool {
+	if rule.Parameters == nil {
+		return false
+	}
+	return json.Unmarshal(*rule.Parameters, params) == nil
+}
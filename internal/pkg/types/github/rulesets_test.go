@@ -0,0 +1,116 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRule(t *testing.T, ruleType string, source string, params any) *github.RepositoryRule {
+	rule := &github.RepositoryRule{Type: ruleType, RulesetSourceType: source}
+	if params != nil {
+		data, err := json.Marshal(params)
+		assert.NoError(t, err)
+		raw := json.RawMessage(data)
+		rule.Parameters = &raw
+	}
+	return rule
+}
+
+func TestRulesetRequirement_AtLeast(t *testing.T) {
+	req := RulesetRequirement{
+		RuleTypes:   []string{"deletion"},
+		PullRequest: &github.PullRequestRuleParameters{RequiredApprovingReviewCount: 1, DismissStaleReviewsOnPush: true},
+	}
+	rules := []*github.RepositoryRule{
+		newTestRule(t, "deletion", OrganizationRulesetSource, nil),
+		newTestRule(t, "non_fast_forward", RepositoryRulesetSource, nil),
+		newTestRule(t, "pull_request", RepositoryRulesetSource, github.PullRequestRuleParameters{RequiredApprovingReviewCount: 2, RequireCodeOwnerReview: true}),
+		newTestRule(t, "pull_request", OrganizationRulesetSource, github.PullRequestRuleParameters{DismissStaleReviewsOnPush: true}),
+	}
+
+	assert.Empty(t, req.Evaluate(rules))
+}
+
+func TestRulesetRequirement_ReportsEachWeakerParameter(t *testing.T) {
+	rules := []*github.RepositoryRule{
+		newTestRule(t, "pull_request", RepositoryRulesetSource, github.PullRequestRuleParameters{RequiredApprovingReviewCount: 0}),
+	}
+
+	violations := defaultBranchRulesetRequirement.Evaluate(rules)
+
+	assert.Equal(t, types.Violations{
+		"required_approving_review_count": "required_approving_review_count is 0. Expected it to be at least 1",
+		"dismiss_stale_reviews_on_push":   "dismiss_stale_reviews_on_push is not enabled. Expected it to be enabled",
+		"require_last_push_approval":      "require_last_push_approval is not enabled. Expected it to be enabled",
+	}, violations)
+}
+
+func TestRulesetRequirement_Sources(t *testing.T) {
+	req := RulesetRequirement{
+		Sources:              []string{RepositoryRulesetSource},
+		RuleTypes:            []string{"deletion"},
+		PullRequest:          &github.PullRequestRuleParameters{},
+		RequiredStatusChecks: []string{"build", "test"},
+	}
+	rules := []*github.RepositoryRule{
+		newTestRule(t, "deletion", OrganizationRulesetSource, nil),
+		newTestRule(t, "required_status_checks", RepositoryRulesetSource, github.RequiredStatusChecksRuleParameters{
+			RequiredStatusChecks: []github.RuleRequiredStatusChecks{{Context: "build"}},
+		}),
+	}
+
+	violations := req.Evaluate(rules)
+
+	assert.Equal(t, types.Violations{
+		"deletion":               "deletion rule is not enforced. Expected it to be enforced",
+		"pull_request":           "pull_request rule is not enforced. Expected pull requests to be required",
+		"required_status_checks": "status checks test are not required. Expected them to be required",
+	}, violations)
+}
+
+func TestRepository_RulesetsRule(t *testing.T) {
+	repo := &Repository{slug: "api", Repository: &github.Repository{Name: github.String("api")}}
+	registry := types.NewCheckRegistry()
+	rule, _ := types.DefaultRegistry.Rule("rulesets")
+	registry.Register(rule)
+	registry.RegisterProfile(types.GoCGuardrails, "rulesets")
+	report := types.CheckReport{EntityType: RepositoryEntityType, Results: make(map[types.CheckType]types.CheckResult)}
+
+	registry.Run(repo, []types.CheckType{types.GoCGuardrails}, &report)
+
+	assert.Equal(t, map[string]string{
+		"rulesets.pull_request": "pull_request rule is not enforced. Expected pull requests to be required",
+	}, report.Errors[0].Violations)
+}
+
+func TestGithubService_GetRepositoriesRulesError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api", "default_branch": "main"}, {"name": "empty"}]`)
+	})
+	mux.HandleFunc("/repos/org/api/rules/branches/main", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"message": "Resource not accessible by integration"}`)
+	})
+	mux.HandleFunc("/repos/org/empty/", func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s for a repository without a default branch", r.URL.Path)
+	})
+	gs := newTestGithubService(t, mux)
+
+	repos, err := gs.GetRepositories("org", RepositoryListOptions{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+
+	rule, _ := types.DefaultRegistry.Rule("rulesets")
+	var evaluationError *types.EvaluationError
+	assert.ErrorAs(t, rule.Evaluate(&repos[0]), &evaluationError)
+	assert.ErrorContains(t, rule.Evaluate(&repos[0]), "unable to read the rules of the default branch: GET")
+	// Repositories without a default branch have no rules
+	assert.Equal(t, types.Violations{"pull_request": "pull_request rule is not enforced. Expected pull requests to be required"}, rule.Evaluate(&repos[1]))
+}
@@ -0,0 +1,305 @@
+package github
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/spf13/afero"
+)
+
+var fs = afero.NewOsFs()
+
+// Incremented whenever the layout of a snapshot changes. Version 2 added the branch protections, the Actions settings,
+// the contents and the alerts of repositories and the errors reading teams and members.
+const SnapshotVersion = 2
+
+// A Snapshot is the data fetched from GitHub for a set of organizations, saved so that checks can be
+// evaluated again later without network access.
+type Snapshot struct {
+	Version       int                    `json:"version"`
+	CreatedAt     time.Time              `json:"created_at"`
+	Organizations []OrganizationSnapshot `json:"organizations"`
+}
+
+type OrganizationSnapshot struct {
+	Organization               *github.Organization     `json:"organization"`
+	CustomRepositoryRoles      []github.CustomRepoRoles `json:"custom_repository_roles"`
+	CustomRepositoryRolesError string                   `json:"custom_repository_roles_error,omitempty"`
+	Actions                    *OrganizationActions     `json:"actions,omitempty"`
+	Repositories               []RepositorySnapshot     `json:"repositories"`
+	Teams                      []TeamSnapshot           `json:"teams"`
+	TeamsError                 string                   `json:"teams_error,omitempty"`
+	Members                    []MemberSnapshot         `json:"members"`
+	MembersError               string                   `json:"members_error,omitempty"`
+}
+
+type TeamSnapshot struct {
+	Team                   *github.Team `json:"team"`
+	Maintainers            []string     `json:"maintainers"`
+	MaintainersError       string       `json:"maintainers_error,omitempty"`
+	Depth                  int          `json:"depth"`
+	AdminRepositories      []string     `json:"admin_repositories"`
+	AdminRepositoriesError string       `json:"admin_repositories_error,omitempty"`
+}
+
+type MemberSnapshot struct {
+	User                *github.User `json:"user"`
+	Role                string       `json:"role,omitempty"`
+	OutsideCollaborator bool         `json:"outside_collaborator"`
+	TwoFactorDisabled   bool         `json:"two_factor_disabled"`
+	AdminRepositories   []string     `json:"admin_repositories,omitempty"`
+	LastActivity        *time.Time   `json:"last_activity,omitempty"`
+	ActivityError       string       `json:"activity_error,omitempty"`
+}
+
+type RepositorySnapshot struct {
+	Repository    *github.Repository       `json:"repository"`
+	Rulesets      []*github.RepositoryRule `json:"rulesets"`
+	RulesetsError string                   `json:"rulesets_error,omitempty"`
+	// Classic protection by branch. Unprotected branches have a null protection.
+	BranchProtections      map[string]*github.Protection `json:"branch_protections,omitempty"`
+	BranchProtectionErrors map[string]string             `json:"branch_protection_errors,omitempty"`
+	Actions                *RepositoryActions            `json:"actions,omitempty"`
+	Contents               *RepositoryContents           `json:"contents,omitempty"`
+	Alerts                 *RepositoryAlerts             `json:"alerts,omitempty"`
+}
+
+// CaptureSnapshot fetches every organization with its repositories, teams and members through the service.
+// Errors listing the teams or the members of an organization are saved in the snapshot and returned when they are read.
+func CaptureSnapshot(gs IGithubService, slugs []string, options RepositoryListOptions) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:       SnapshotVersion,
+		CreatedAt:     time.Now().UTC(),
+		Organizations: []OrganizationSnapshot{},
+	}
+
+	for _, slug := range slugs {
+		// The Actions settings of the organization are captured along with those of its repositories
+		org, err := gs.GetOrganization(slug, OrganizationOptions{ActionsSettings: options.ActionsSettings})
+		if err != nil {
+			return nil, fmt.Errorf("unable to get organization %q: %w", slug, err)
+		}
+		repos, err := gs.GetRepositories(slug, options, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get repositories of %q: %w", slug, err)
+		}
+
+		teams, teamsErr := gs.GetTeams(slug)
+		members, membersErr := gs.GetMembers(slug, MemberOptions{Activity: true})
+
+		orgSnapshot := OrganizationSnapshot{
+			Organization:               org.Organization,
+			CustomRepositoryRoles:      org.customRepositoryRoles,
+			CustomRepositoryRolesError: org.customRepositoryRolesError,
+			Actions:                    org.actions,
+			Repositories:               make([]RepositorySnapshot, 0, len(repos)),
+			Teams:                      make([]TeamSnapshot, 0, len(teams)),
+			Members:                    make([]MemberSnapshot, 0, len(members)),
+		}
+		if teamsErr != nil {
+			orgSnapshot.TeamsError = teamsErr.Error()
+		}
+		if membersErr != nil {
+			orgSnapshot.MembersError = membersErr.Error()
+		}
+		for _, t := range teams {
+			orgSnapshot.Teams = append(orgSnapshot.Teams, TeamSnapshot{
+				Team:                   t.Team,
+				Maintainers:            t.maintainers,
+				MaintainersError:       t.maintainersError,
+				Depth:                  t.depth,
+				AdminRepositories:      t.adminRepositories,
+				AdminRepositoriesError: t.adminRepositoriesError,
+			})
+		}
+		for _, m := range members {
+			orgSnapshot.Members = append(orgSnapshot.Members, MemberSnapshot{
+				User:                m.User,
+				Role:                m.role,
+				OutsideCollaborator: m.outsideCollaborator,
+				TwoFactorDisabled:   m.twoFactorDisabled,
+				AdminRepositories:   m.adminRepositories,
+				LastActivity:        m.lastActivity,
+				ActivityError:       m.activityError,
+			})
+		}
+		for _, r := range repos {
+			orgSnapshot.Repositories = append(orgSnapshot.Repositories, RepositorySnapshot{
+				Repository:             r.Repository,
+				Rulesets:               r.rulesets,
+				RulesetsError:          r.rulesetsError,
+				BranchProtections:      r.branchProtections,
+				BranchProtectionErrors: r.branchProtectionErrors,
+				Actions:                r.actions,
+				Contents:               r.contents,
+				Alerts:                 r.alerts,
+			})
+		}
+		snapshot.Organizations = append(snapshot.Organizations, orgSnapshot)
+	}
+	return snapshot, nil
+}
+
+func (s *Snapshot) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+func LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read snapshot %q: %w", path, err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("unable to parse snapshot %q: %w", path, err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d. Expected version %d", snapshot.Version, SnapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+// Slugs returns the login of every organization in the snapshot
+func (s *Snapshot) Slugs() []string {
+	slugs := make([]string, 0, len(s.Organizations))
+	for _, o := range s.Organizations {
+		slugs = append(slugs, o.Organization.GetLogin())
+	}
+	return slugs
+}
+
+func (s *Snapshot) organization(slug string) (*OrganizationSnapshot, error) {
+	for i := range s.Organizations {
+		if s.Organizations[i].Organization.GetLogin() == slug {
+			return &s.Organizations[i], nil
+		}
+	}
+	return nil, fmt.Errorf("organization %q is not in the snapshot", slug)
+}
+
+// SnapshotService serves the data of a snapshot instead of calling the GitHub API
+type SnapshotService struct {
+	Snapshot *Snapshot
+}
+
+func (s *SnapshotService) GetOrganization(slug string, options OrganizationOptions) (Organization, error) {
+	o, err := s.Snapshot.organization(slug)
+	if err != nil {
+		return Organization{}, err
+	}
+	return Organization{
+		Organization:               o.Organization,
+		customRepositoryRoles:      o.CustomRepositoryRoles,
+		customRepositoryRolesError: o.CustomRepositoryRolesError,
+		actions:                    o.Actions,
+	}, nil
+}
+
+func (s *SnapshotService) GetRepositories(owner string, options RepositoryListOptions, filterFn func(r Repository) bool) ([]Repository, error) {
+	o, err := s.Snapshot.organization(owner)
+	if err != nil {
+		return []Repository{}, err
+	}
+
+	var repositories []Repository
+	for _, r := range o.Repositories {
+		if !options.matchesType(r.Repository) || !options.matches(r.Repository) {
+			continue
+		}
+		repo := Repository{
+			slug:                   r.Repository.GetName(),
+			rulesets:               r.Rulesets,
+			rulesetsError:          r.RulesetsError,
+			branchProtections:      r.BranchProtections,
+			branchProtectionErrors: r.BranchProtectionErrors,
+			actions:                r.Actions,
+			contents:               r.Contents,
+			alerts:                 r.Alerts,
+			licenseTemplate:        options.licenseTemplate(owner, r.Repository),
+			alertMaxAges:           options.alertMaxAges(),
+			hygieneRequirements:    options.hygieneRequirements(),
+			scannedAt:              s.Snapshot.CreatedAt,
+			Repository:             r.Repository,
+		}
+		if filterFn != nil && !filterFn(repo) {
+			continue
+		}
+		repositories = append(repositories, repo)
+	}
+	return repositories, nil
+}
+
+func (s *SnapshotService) GetTeams(org string) ([]Team, error) {
+	o, err := s.Snapshot.organization(org)
+	if err != nil {
+		return nil, err
+	}
+	if o.TeamsError != "" {
+		return nil, errors.New(o.TeamsError)
+	}
+	teams := make([]Team, 0, len(o.Teams))
+	for _, t := range o.Teams {
+		teams = append(teams, Team{
+			Team:                   t.Team,
+			maintainers:            t.Maintainers,
+			maintainersError:       t.MaintainersError,
+			depth:                  t.Depth,
+			adminRepositories:      t.AdminRepositories,
+			adminRepositoriesError: t.AdminRepositoriesError,
+		})
+	}
+	return teams, nil
+}
+
+func (s *SnapshotService) GetMembers(org string, options MemberOptions) ([]Member, error) {
+	o, err := s.Snapshot.organization(org)
+	if err != nil {
+		return nil, err
+	}
+	if o.MembersError != "" {
+		return nil, errors.New(o.MembersError)
+	}
+	members := make([]Member, 0, len(o.Members))
+	for _, m := range o.Members {
+		members = append(members, Member{
+			User:                m.User,
+			role:                m.Role,
+			outsideCollaborator: m.OutsideCollaborator,
+			twoFactorDisabled:   m.TwoFactorDisabled,
+			adminRepositories:   m.AdminRepositories,
+			lastActivity:        m.LastActivity,
+			activityFetched:     true,
+			activityError:       m.ActivityError,
+			scannedAt:           s.Snapshot.CreatedAt,
+		})
+	}
+	return members, nil
+}
+
+// Snapshots do not record enterprises. Organizations of the snapshot are selected by their slug instead.
+func (s *SnapshotService) GetEnterpriseOrganizations(enterprise string) ([]string, error) {
+	return nil, fmt.Errorf("unable to list the organizations of enterprise %q from a snapshot", enterprise)
+}
+
+// The API filters repositories by type. Snapshots hold every repository that was fetched so the type is filtered locally.
+func (o RepositoryListOptions) matchesType(r *github.Repository) bool {
+	switch o.Type {
+	case "public":
+		return r.GetVisibility() == "public"
+	case "private":
+		return r.GetVisibility() != "public"
+	case "forks":
+		return r.GetFork()
+	case "sources":
+		return !r.GetFork()
+	default:
+		return true
+	}
+}
@@ -0,0 +1,176 @@
+package github
+
+import (
+	"bytes"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "org", "members_can_create_public_repositories": true}`)
+	})
+	mux.HandleFunc("/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 1, "custom_roles": [{"name": "Contractor", "base_role": "write", "permissions": ["manage_webhooks"]}]}`)
+	})
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"name": "api", "default_branch": "main", "visibility": "private"}, {"name": "fork", "default_branch": "main", "fork": true, "visibility": "public"}]`)
+	})
+	mux.HandleFunc("/repos/org/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"type": "deletion"}]`)
+	})
+	mux.HandleFunc("/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 1, "slug": "core", "privacy": "secret"}]`)
+	})
+	mux.HandleFunc("/orgs/org/teams/core/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/orgs/org/members", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("role") == "admin" {
+			fmt.Fprint(w, `[{"login": "owner"}]`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/orgs/org/outside_collaborators", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/users/owner/events", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	gs := newTestGithubService(t, mux)
+
+	snapshot, err := CaptureSnapshot(gs, []string{"org"}, RepositoryListOptions{})
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, snapshot.Write(&buf))
+	fs = afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "snapshot.json", buf.Bytes(), 0644))
+
+	loaded, err := LoadSnapshot("snapshot.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"org"}, loaded.Slugs())
+
+	offline := &SnapshotService{Snapshot: loaded}
+	org, err := offline.GetOrganization("org", OrganizationOptions{})
+	assert.NoError(t, err)
+	live, _ := gs.GetOrganization("org", OrganizationOptions{})
+	liveRoles, _ := live.CustomRepositoryRoles()
+	offlineRoles, err := org.CustomRepositoryRoles()
+	assert.NoError(t, err)
+	assert.Equal(t, liveRoles, offlineRoles)
+	assert.Equal(t, live.Check(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails}).Results, org.Check(types.DefaultRegistry, []types.CheckType{types.GoCGuardrails}).Results)
+
+	repos, err := offline.GetRepositories("org", RepositoryListOptions{}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 2)
+	assert.Equal(t, "api", repos[0].slug)
+	assert.Len(t, repos[0].rulesets, 1)
+	assert.Equal(t, "deletion", repos[0].rulesets[0].Type)
+
+	repos, err = offline.GetRepositories("org", RepositoryListOptions{Type: "sources"}, nil)
+	assert.NoError(t, err)
+	assert.Len(t, repos, 1)
+
+	teams, err := offline.GetTeams("org")
+	assert.NoError(t, err)
+	assert.Len(t, teams, 1)
+	assert.Equal(t, 1, teams[0].depth)
+	members, err := offline.GetMembers("org", MemberOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+	assert.Equal(t, "admin", members[0].role)
+
+	_, err = offline.GetOrganization("other", OrganizationOptions{})
+	assert.Error(t, err)
+}
+
+func TestLoadSnapshot_UnsupportedVersion(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "snapshot.json", []byte(`{"version": 99, "organizations": []}`), 0644)
+
+	_, err := LoadSnapshot("snapshot.json")
+
+	assert.ErrorContains(t, err, "unsupported snapshot version 99")
+}
+
+func TestCaptureSnapshot_MembersError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"login": "org"}`)
+	})
+	mux.HandleFunc("/orgs/org/custom-repository-roles", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"total_count": 0, "custom_roles": []}`)
+	})
+	mux.HandleFunc("/orgs/org/repos", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/orgs/org/members", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	gs := newTestGithubService(t, mux)
+
+	snapshot, err := CaptureSnapshot(gs, []string{"org"}, RepositoryListOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, snapshot.Organizations[0].MembersError, "unable to list the members of")
+
+	offline := &SnapshotService{Snapshot: snapshot}
+	teams, err := offline.GetTeams("org")
+	assert.NoError(t, err)
+	assert.Empty(t, teams)
+	_, err = offline.GetMembers("org", MemberOptions{})
+	assert.ErrorContains(t, err, "unable to list the members of")
+}
+
+func TestSnapshotService_MemberActivityAtCaptureTime(t *testing.T) {
+	captured := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	active := captured.AddDate(0, 0, -10)
+	snapshot := &Snapshot{Version: SnapshotVersion, CreatedAt: captured, Organizations: []OrganizationSnapshot{{
+		Organization: &github.Organization{Login: github.String("org")},
+		Members: []MemberSnapshot{
+			{User: &github.User{Login: github.String("alice")}, Role: "member", LastActivity: &active},
+		},
+	}}}
+	offline := &SnapshotService{Snapshot: snapshot}
+
+	members, err := offline.GetMembers("org", MemberOptions{})
+	assert.NoError(t, err)
+
+	// Alice was active 10 days before the capture, however long ago that was
+	dormant, ok := types.DefaultRegistry.RuleForKey("member_dormant")
+	assert.True(t, ok)
+	assert.NoError(t, dormant.Evaluate(&members[0]))
+}
+
+func TestSnapshotService_AlertAgesAtCaptureTime(t *testing.T) {
+	captured := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := &Snapshot{Version: SnapshotVersion, CreatedAt: captured, Organizations: []OrganizationSnapshot{{
+		Organization: &github.Organization{Login: github.String("org")},
+		Repositories: []RepositorySnapshot{{
+			Repository: &github.Repository{Name: github.String("api")},
+			Alerts: &RepositoryAlerts{Open: map[string][]SecurityAlert{
+				DependabotAlerts: {{Number: 1, Severity: "critical", CreatedAt: captured.AddDate(0, 0, -10)}},
+			}},
+		}},
+	}}}
+	offline := &SnapshotService{Snapshot: snapshot}
+
+	repos, err := offline.GetRepositories("org", RepositoryListOptions{}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, captured, repos[0].ScannedAt())
+	// The alert was 10 days old when the snapshot was captured, however long ago that was
+	assert.NoError(t, evaluateAlerts(&repos[0], DependabotAlerts))
+}
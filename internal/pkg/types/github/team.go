@@ -0,0 +1,171 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v61/github"
+)
+
+const TeamEntityType = "github_team"
+
+const (
+	minTeamMaintainers  = 2
+	maxTeamNestingDepth = 3
+)
+
+type Team struct {
+	*github.Team
+	maintainers      []string
+	maintainersError string
+	// Number of levels from the root team, the root team being at depth 1
+	depth int
+	// Repositories the team administers
+	adminRepositories      []string
+	adminRepositoriesError string
+}
+
+func (t *Team) Check(registry *types.CheckRegistry, checkTypes []types.CheckType) types.CheckReport {
+	report := types.CheckReport{
+		EntityType: TeamEntityType,
+		EntityId:   t.GetSlug(),
+		Timestamp:  time.Now().Format(time.RFC3339), // Syslog compliant timestamp
+		Results:    make(map[types.CheckType]types.CheckResult),
+		Errors:     []types.CheckError{},
+	}
+	registry.Run(t, checkTypes, &report)
+	return report
+}
+
+// GetTeams lists the teams of the organization with their maintainers and the repositories they administer.
+// Errors listing the maintainers or the repositories of a team are kept on the team and reported by the rules that need them.
+func (g *GithubService) GetTeams(org string) ([]Team, error) {
+	ghTeams, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.Team, *github.Response, error) {
+		return g.client.Teams.ListTeams(ctx, org, &opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make([]Team, len(ghTeams))
+	depths := teamDepths(ghTeams)
+	g.forEach(len(ghTeams), func(i int) {
+		t := ghTeams[i]
+		teams[i] = Team{Team: t, depth: depths[t.GetID()]}
+
+		maintainers, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.User, *github.Response, error) {
+			return g.client.Teams.ListTeamMembersBySlug(ctx, org, t.GetSlug(), &github.TeamListTeamMembersOptions{Role: "maintainer", ListOptions: opts})
+		})
+		if err != nil {
+			teams[i].maintainersError = err.Error()
+		}
+		for _, m := range maintainers {
+			teams[i].maintainers = append(teams[i].maintainers, m.GetLogin())
+		}
+
+		repos, err := listAll(g, func(ctx context.Context, opts github.ListOptions) ([]*github.Repository, *github.Response, error) {
+			return g.client.Teams.ListTeamReposBySlug(ctx, org, t.GetSlug(), &opts)
+		})
+		if err != nil {
+			teams[i].adminRepositoriesError = err.Error()
+		}
+		for _, r := range repos {
+			if r.GetPermissions()["admin"] {
+				teams[i].adminRepositories = append(teams[i].adminRepositories, r.GetName())
+			}
+		}
+	})
+	return teams, nil
+}
+
+// teamDepths computes the nesting depth of every team from the parents of the teams
+func teamDepths(teams []*github.Team) map[int64]int {
+	parents := make(map[int64]int64, len(teams))
+	for _, t := range teams {
+		if t.Parent != nil {
+			parents[t.GetID()] = t.Parent.GetID()
+		}
+	}
+
+	depths := make(map[int64]int, len(teams))
+	for _, t := range teams {
+		depth := 1
+		// Bounded by the number of teams in case the parents form a cycle
+		for id, ok := parents[t.GetID()]; ok && depth <= len(teams); id, ok = parents[id] {
+			depth++
+		}
+		depths[t.GetID()] = depth
+	}
+	return depths
+}
+
+var teamRules = []types.Rule{
+	{
+		Id:          "team_maintainers",
+		Description: fmt.Sprintf("Teams have at least %d maintainers", minTeamMaintainers),
+		Severity:    types.Medium,
+		EntityType:  TeamEntityType,
+		Evaluate: types.EvaluateAs(func(team *Team) error {
+			if team.maintainersError != "" {
+				return types.EvaluationErrorf("unable to list the maintainers of the team: %s", team.maintainersError)
+			}
+			if len(team.maintainers) < minTeamMaintainers {
+				return fmt.Errorf("team has %d maintainers. Expected at least %d", len(team.maintainers), minTeamMaintainers)
+			}
+			return nil
+		}),
+	},
+	{
+		Id:          "team_privacy",
+		Description: "Teams are visible to every member of the organization",
+		Severity:    types.Low,
+		EntityType:  TeamEntityType,
+		Evaluate: types.EvaluateAs(func(team *Team) error {
+			if team.GetPrivacy() != "closed" {
+				return fmt.Errorf("privacy is %s. Expected it to be closed", team.GetPrivacy())
+			}
+			return nil
+		}),
+	},
+	{
+		Id:          "team_nesting_depth",
+		Description: fmt.Sprintf("Teams are nested at most %d levels deep", maxTeamNestingDepth),
+		Severity:    types.Low,
+		EntityType:  TeamEntityType,
+		Evaluate: types.EvaluateAs(func(team *Team) error {
+			if team.depth > maxTeamNestingDepth {
+				return fmt.Errorf("team is nested %d levels deep. Expected at most %d", team.depth, maxTeamNestingDepth)
+			}
+			return nil
+		}),
+	},
+	{
+		Id:          "team_repository_admin",
+		Description: "Teams do not administer repositories",
+		Severity:    types.Medium,
+		EntityType:  TeamEntityType,
+		Evaluate: types.EvaluateAs(func(team *Team) error {
+			if team.adminRepositoriesError != "" {
+				return types.EvaluationErrorf("unable to list the repositories of the team: %s", team.adminRepositoriesError)
+			}
+			if len(team.adminRepositories) > 0 {
+				return fmt.Errorf("team has admin access to %s. Expected it to have no admin access", strings.Join(team.adminRepositories, ", "))
+			}
+			return nil
+		}),
+	},
+}
+
+func init() {
+	if err := types.DefaultRegistry.Register(teamRules...); err != nil {
+		panic(err)
+	}
+	for _, rule := range teamRules {
+		if err := types.DefaultRegistry.RegisterProfile(types.Membership, rule.Id); err != nil {
+			panic(err)
+		}
+	}
+}
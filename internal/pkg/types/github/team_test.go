@@ -0,0 +1,95 @@
+package github
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamDepths(t *testing.T) {
+	teams := []*github.Team{
+		{ID: github.Int64(1)},
+		{ID: github.Int64(2), Parent: &github.Team{ID: github.Int64(1)}},
+		{ID: github.Int64(3), Parent: &github.Team{ID: github.Int64(2)}},
+	}
+
+	depths := teamDepths(teams)
+
+	assert.Equal(t, map[int64]int{1: 1, 2: 2, 3: 3}, depths)
+}
+
+func TestGithubService_GetTeams(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"id": 1, "slug": "a", "privacy": "closed"},
+			{"id": 2, "slug": "b", "privacy": "closed", "parent": {"id": 1}},
+			{"id": 3, "slug": "c", "privacy": "closed", "parent": {"id": 2}},
+			{"id": 4, "slug": "d", "privacy": "secret", "parent": {"id": 3}}
+		]`)
+	})
+	mux.HandleFunc("/orgs/org/teams/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/org/teams/a/members":
+			assert.Equal(t, "maintainer", r.URL.Query().Get("role"))
+			fmt.Fprint(w, `[{"login": "alice"}, {"login": "bob"}]`)
+		case "/orgs/org/teams/a/repos":
+			fmt.Fprint(w, `[{"name": "api", "permissions": {"admin": false, "push": true}}]`)
+		case "/orgs/org/teams/d/repos":
+			fmt.Fprint(w, `[{"name": "api", "permissions": {"admin": true}}, {"name": "docs", "permissions": {"admin": true}}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+
+	teams, err := gs.GetTeams("org")
+	assert.NoError(t, err)
+	assert.Len(t, teams, 4)
+
+	report := teams[0].Check(types.DefaultRegistry, []types.CheckType{types.Membership})
+	assert.Equal(t, types.Passed, report.Results[types.Membership])
+	assert.Equal(t, TeamEntityType, report.EntityType)
+
+	report = teams[3].Check(types.DefaultRegistry, []types.CheckType{types.Membership})
+	assert.Equal(t, types.Failed, report.Results[types.Membership])
+	assert.Equal(t, map[string]string{
+		"team_maintainers":      "team has 0 maintainers. Expected at least 2",
+		"team_privacy":          "privacy is secret. Expected it to be closed",
+		"team_nesting_depth":    "team is nested 4 levels deep. Expected at most 3",
+		"team_repository_admin": "team has admin access to api, docs. Expected it to have no admin access",
+	}, report.Errors[0].Violations)
+}
+
+func TestGithubService_GetTeamsUnreadable(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orgs/org/teams", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": 1, "slug": "a", "privacy": "closed"}, {"id": 2, "slug": "b", "privacy": "closed"}]`)
+	})
+	mux.HandleFunc("/orgs/org/teams/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/org/teams/a/members":
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+		case "/orgs/org/teams/b/members":
+			fmt.Fprint(w, `[{"login": "alice"}, {"login": "bob"}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	gs := newTestGithubService(t, mux)
+
+	teams, err := gs.GetTeams("org")
+	assert.NoError(t, err)
+	assert.Len(t, teams, 2)
+
+	report := teams[0].Check(types.DefaultRegistry, []types.CheckType{types.Membership})
+	assert.Equal(t, types.Errored, report.Results[types.Membership])
+	assert.Contains(t, report.Errors[0].Unevaluated["team_maintainers"], "unable to list the maintainers of the team")
+
+	report = teams[1].Check(types.DefaultRegistry, []types.CheckType{types.Membership})
+	assert.Equal(t, types.Passed, report.Results[types.Membership])
+}
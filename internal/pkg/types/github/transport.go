@@ -0,0 +1,198 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// GitHub asks clients to wait at least a minute after a secondary rate limit without a Retry-After header
+	secondaryRateLimitWait = time.Minute
+	backoffBase            = time.Second
+	backoffMax             = time.Minute
+)
+
+// rateLimitTransport retries requests rejected by the primary or secondary rate limits once the limit resets,
+// and retries server errors of idempotent requests with an exponential backoff. Every attempt gets its own
+// timeout, so the waits between attempts are only bounded by the context of the request.
+type rateLimitTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	// Timeout of a single attempt, none when 0
+	timeout time.Duration
+	// Replaced in tests to avoid waiting
+	sleep func(ctx context.Context, d time.Duration) error
+	now   func() time.Time
+}
+
+func newRateLimitTransport(base http.RoundTripper, maxRetries int, timeout time.Duration) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{
+		base:       base,
+		maxRetries: maxRetries,
+		timeout:    timeout,
+		sleep:      sleepContext,
+		now:        time.Now,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// attemptContext returns the context of an attempt of a request, with the timeout of a single attempt
+func (t *rateLimitTransport) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, t.timeout)
+}
+
+// A response body cancelling the context of its attempt once it is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := t.attemptContext(ctx)
+		attemptReq := req.Clone(attemptCtx)
+		if attempt > 0 && req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil {
+			cancel()
+			if attempt >= t.maxRetries || !isIdempotent(req.Method) || ctx.Err() != nil {
+				return nil, err
+			}
+			if err := t.sleep(ctx, backoff(attempt)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		wait, retry := t.retryAfter(req, resp, attempt)
+		if !retry || attempt >= t.maxRetries {
+			// The request used the last of the primary rate limit. Hold the response until the limit resets
+			// so that the client does not reject the following requests without sending them. The body is
+			// read first since the attempt may time out during the wait.
+			if reset, exhausted := t.primaryReset(resp); exhausted && resp.StatusCode < 400 {
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				cancel()
+				if err != nil {
+					return nil, err
+				}
+				resp.Body = io.NopCloser(bytes.NewReader(body))
+				// The response is returned even when the wait is cancelled, since it succeeded
+				t.sleep(ctx, reset.Sub(t.now()))
+				return resp, nil
+			}
+			resp.Body = &cancelOnClose{resp.Body, cancel}
+			return resp, nil
+		}
+
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		cancel()
+		if err := t.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying the request and whether it should be retried at all
+func (t *rateLimitTransport) retryAfter(req *http.Request, resp *http.Response, attempt int) (time.Duration, bool) {
+	switch resp.StatusCode {
+	case http.StatusForbidden, http.StatusTooManyRequests:
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if reset, exhausted := t.primaryReset(resp); exhausted {
+			return reset.Sub(t.now()), true
+		}
+		if isSecondaryRateLimit(resp) {
+			return secondaryRateLimitWait, true
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return backoff(attempt), true
+		}
+		// Any other forbidden response is a permission error that will not go away
+		return 0, false
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return backoff(attempt), isIdempotent(req.Method)
+	default:
+		return 0, false
+	}
+}
+
+// primaryReset returns when the primary rate limit resets if the response reports it as exhausted
+func (t *rateLimitTransport) primaryReset(resp *http.Response) (time.Time, bool) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+	epoch, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	reset := time.Unix(epoch, 0).Add(time.Second)
+	return reset, reset.After(t.now())
+}
+
+// Secondary rate limits are only identified by the message of the response.
+// The body is buffered so that it can still be read by the caller.
+func isSecondaryRateLimit(resp *http.Response) bool {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return strings.Contains(strings.ToLower(string(body)), "secondary rate limit")
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func backoff(attempt int) time.Duration {
+	d := time.Duration(float64(backoffBase) * math.Pow(2, float64(attempt)))
+	if d > backoffMax {
+		return backoffMax
+	}
+	return d
+}
@@ -0,0 +1,212 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+
+// Serves the given handlers in order, one per request, and records how long the transport waited between them
+type transportTest struct {
+	server   *httptest.Server
+	client   *http.Client
+	requests int
+	waits    []time.Duration
+	mu       sync.Mutex
+}
+
+func newTransportTest(t *testing.T, maxRetries int, handlers ...http.HandlerFunc) *transportTest {
+	tt := &transportTest{}
+	tt.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tt.mu.Lock()
+		handler := handlers[min(tt.requests, len(handlers)-1)]
+		tt.requests++
+		tt.mu.Unlock()
+		handler(w, r)
+	}))
+	t.Cleanup(tt.server.Close)
+
+	transport := newRateLimitTransport(http.DefaultTransport, maxRetries, time.Minute)
+	transport.now = func() time.Time { return testNow }
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		tt.waits = append(tt.waits, d)
+		return nil
+	}
+	tt.client = &http.Client{Transport: transport}
+	return tt
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, `{}`)
+}
+
+func status(code int, headers map[string]string, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for key, value := range headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(code)
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestRateLimitTransport_RetryAfter(t *testing.T) {
+	tt := newTransportTest(t, 3,
+		status(http.StatusForbidden, map[string]string{"Retry-After": "30"}, `{"message": "You have exceeded a secondary rate limit."}`),
+		ok,
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, tt.requests)
+	assert.Equal(t, []time.Duration{30 * time.Second}, tt.waits)
+}
+
+func TestRateLimitTransport_SecondaryWithoutRetryAfter(t *testing.T) {
+	tt := newTransportTest(t, 3,
+		status(http.StatusForbidden, nil, `{"message": "You have exceeded a secondary rate limit."}`),
+		ok,
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{secondaryRateLimitWait}, tt.waits)
+}
+
+func TestRateLimitTransport_PrimaryRateLimit(t *testing.T) {
+	reset := fmt.Sprint(testNow.Add(time.Minute).Unix())
+	tt := newTransportTest(t, 3,
+		status(http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, `{"message": "API rate limit exceeded"}`),
+		ok,
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{61 * time.Second}, tt.waits)
+}
+
+func TestRateLimitTransport_HoldsLastRequestOfTheLimit(t *testing.T) {
+	reset := fmt.Sprint(testNow.Add(time.Minute).Unix())
+	tt := newTransportTest(t, 3,
+		status(http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, `{}`),
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, tt.requests)
+	assert.Equal(t, []time.Duration{61 * time.Second}, tt.waits)
+}
+
+func TestRateLimitTransport_ServerErrorBackoff(t *testing.T) {
+	tt := newTransportTest(t, 3,
+		status(http.StatusBadGateway, nil, ""),
+		status(http.StatusServiceUnavailable, nil, ""),
+		ok,
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, tt.waits)
+}
+
+func TestRateLimitTransport_ServerErrorNotRetriedForPost(t *testing.T) {
+	tt := newTransportTest(t, 3,
+		status(http.StatusBadGateway, nil, ""),
+		ok,
+	)
+
+	resp, err := tt.client.Post(tt.server.URL, "application/json", strings.NewReader(`{}`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, 1, tt.requests)
+}
+
+func TestRateLimitTransport_PermissionErrorNotRetried(t *testing.T) {
+	tt := newTransportTest(t, 3,
+		status(http.StatusForbidden, nil, `{"message": "Resource not accessible by integration"}`),
+		ok,
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, 1, tt.requests)
+	assert.Empty(t, tt.waits)
+}
+
+func TestRateLimitTransport_MaxRetries(t *testing.T) {
+	tt := newTransportTest(t, 2,
+		status(http.StatusTooManyRequests, nil, ""),
+	)
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 3, tt.requests)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, tt.waits)
+}
+
+// Rate limit headers of a limit resetting in one to two seconds, after the timeout of a single attempt has passed
+func resetSoon() (time.Time, map[string]string) {
+	reset := time.Now().Add(2 * time.Second).Truncate(time.Second)
+	// The transport waits one more second than the reset GitHub reports
+	return reset, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": fmt.Sprint(reset.Add(-time.Second).Unix())}
+}
+
+func TestRateLimitTransport_RetryWaitsLongerThanTheRequestTimeout(t *testing.T) {
+	reset, headers := resetSoon()
+	tt := newTransportTest(t, 3,
+		status(http.StatusForbidden, headers, `{"message": "API rate limit exceeded"}`),
+		status(http.StatusOK, nil, `{"login": "org"}`),
+	)
+	tt.client = &http.Client{Transport: newRateLimitTransport(http.DefaultTransport, 3, 100*time.Millisecond)}
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"login": "org"}`, string(body))
+	assert.False(t, time.Now().Before(reset))
+	assert.Equal(t, 2, tt.requests)
+}
+
+func TestRateLimitTransport_HoldWaitsLongerThanTheRequestTimeout(t *testing.T) {
+	reset, headers := resetSoon()
+	tt := newTransportTest(t, 3, status(http.StatusOK, headers, `{"login": "org"}`))
+	tt.client = &http.Client{Transport: newRateLimitTransport(http.DefaultTransport, 3, 100*time.Millisecond)}
+
+	resp, err := tt.client.Get(tt.server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"login": "org"}`, string(body))
+	assert.False(t, time.Now().Before(reset))
+	assert.Equal(t, 1, tt.requests)
+}
@@ -0,0 +1,149 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The parts of a GitHub Actions workflow read by the rules
+type workflow struct {
+	On          any                    `yaml:"on"`
+	Permissions any                    `yaml:"permissions"`
+	Jobs        map[string]workflowJob `yaml:"jobs"`
+}
+
+type workflowJob struct {
+	Steps []workflowStep `yaml:"steps"`
+}
+
+type workflowStep struct {
+	Uses string         `yaml:"uses"`
+	Run  string         `yaml:"run"`
+	With map[string]any `yaml:"with"`
+}
+
+func parseWorkflow(content string) (*workflow, error) {
+	var w workflow
+	if err := yaml.Unmarshal([]byte(content), &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// triggers returns the events that trigger the workflow. "on" is either an event, a list of events or a mapping by event.
+func (w *workflow) triggers() []string {
+	switch on := w.On.(type) {
+	case string:
+		return []string{on}
+	case []any:
+		var events []string
+		for _, e := range on {
+			events = append(events, fmt.Sprint(e))
+		}
+		return events
+	case map[any]any:
+		var events []string
+		for e := range on {
+			events = append(events, fmt.Sprint(e))
+		}
+		return events
+	default:
+		return nil
+	}
+}
+
+// Events whose workflows run with secrets and a write token even when triggered from a fork
+var privilegedTriggers = []string{"pull_request_target", "workflow_run"}
+
+// References to the code of a pull request, which is controlled by its author
+var untrustedRef = regexp.MustCompile(`github\.event\.pull_request\.head\.(ref|sha)|github\.head_ref|github\.event\.workflow_run\.head_(branch|sha)`)
+
+// Expressions whose value is controlled by whoever opens an issue, a pull request or a comment.
+// Interpolating them in a script lets them inject commands.
+var untrustedExpression = regexp.MustCompile(`\$\{\{[^}]*(` + strings.Join([]string{
+	`github\.event\.issue\.(title|body)`,
+	`github\.event\.pull_request\.(title|body|head\.ref|head\.label|head\.repo\.default_branch)`,
+	`github\.event\.(comment|review|review_comment)\.body`,
+	`github\.event\.discussion\.(title|body)`,
+	`github\.event\.pages\.[^.}]+\.page_name`,
+	`github\.event\.(commits\.[^.}]+|head_commit)\.(message|author\.(email|name))`,
+	`github\.event\.workflow_run\.(head_branch|head_commit\.message|head_commit\.author\.(email|name)|display_title)`,
+	`github\.head_ref`,
+}, "|") + `)[^}]*\}\}`)
+
+// dangerousWorkflowPatterns reports the untrusted checkouts in privileged workflows and the script injections of the workflow
+func dangerousWorkflowPatterns(w *workflow) map[string]string {
+	violations := make(map[string]string)
+
+	privileged := false
+	for _, trigger := range w.triggers() {
+		for _, p := range privilegedTriggers {
+			if trigger == p {
+				privileged = true
+			}
+		}
+	}
+
+	var checkouts, injections []string
+	for _, name := range sortedJobs(w) {
+		for _, step := range w.Jobs[name].Steps {
+			if privileged && strings.HasPrefix(step.Uses, "actions/checkout@") && untrustedRef.MatchString(fmt.Sprint(step.With["ref"])) {
+				checkouts = append(checkouts, name)
+			}
+			script := step.Run
+			if strings.HasPrefix(step.Uses, "actions/github-script@") {
+				script = fmt.Sprint(step.With["script"])
+			}
+			if expression := untrustedExpression.FindString(script); expression != "" {
+				injections = append(injections, fmt.Sprintf("%s (%s)", name, expression))
+			}
+		}
+	}
+
+	if len(checkouts) > 0 {
+		violations["untrusted_checkout"] = fmt.Sprintf("jobs %s check out the code of a pull request in a privileged workflow. Expected privileged workflows to only run trusted code",
+			strings.Join(checkouts, ", "))
+	}
+	if len(injections) > 0 {
+		violations["script_injection"] = fmt.Sprintf("jobs %s interpolate untrusted input in a script. Expected untrusted input to be passed through environment variables",
+			strings.Join(injections, ", "))
+	}
+	return violations
+}
+
+// workflowTokenPermissions reports workflows that do not restrict the top-level permissions of the GITHUB_TOKEN to read access
+func workflowTokenPermissions(w *workflow) map[string]string {
+	switch permissions := w.Permissions.(type) {
+	case nil:
+		return map[string]string{"": "top-level permissions are not declared. Expected them to be read only"}
+	case string:
+		if permissions != "read-all" {
+			return map[string]string{"": fmt.Sprintf("top-level permissions are %s. Expected them to be read only", permissions)}
+		}
+	case map[any]any:
+		var writable []string
+		for scope, access := range permissions {
+			if fmt.Sprint(access) == "write" {
+				writable = append(writable, fmt.Sprint(scope))
+			}
+		}
+		sort.Strings(writable)
+		if len(writable) > 0 {
+			return map[string]string{"": fmt.Sprintf("top-level permissions grant write access to %s. Expected them to be read only", strings.Join(writable, ", "))}
+		}
+	}
+	return nil
+}
+
+func sortedJobs(w *workflow) []string {
+	names := make([]string, 0, len(w.Jobs))
+	for name := range w.Jobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,80 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const privilegedWorkflow = `
+on:
+  pull_request_target:
+    types: [opened]
+permissions: write-all
+jobs:
+  build:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          ref: ${{ github.event.pull_request.head.sha }}
+      - run: echo "${{ github.event.pull_request.title }}"
+  label:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/github-script@v7
+        with:
+          script: console.log("${{ github.event.issue.body }}")
+`
+
+const safeWorkflow = `
+on: [push, pull_request]
+permissions:
+  contents: read
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: echo "$TITLE"
+        env:
+          TITLE: ${{ github.event.pull_request.title }}
+`
+
+func TestDangerousWorkflowPatterns(t *testing.T) {
+	w, err := parseWorkflow(privilegedWorkflow)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pull_request_target"}, w.triggers())
+
+	violations := dangerousWorkflowPatterns(w)
+
+	assert.Contains(t, violations["untrusted_checkout"], "jobs build check out the code of a pull request")
+	assert.Contains(t, violations["script_injection"], "build (${{ github.event.pull_request.title }})")
+	assert.Contains(t, violations["script_injection"], "label (${{ github.event.issue.body }})")
+}
+
+func TestDangerousWorkflowPatterns_Safe(t *testing.T) {
+	w, err := parseWorkflow(safeWorkflow)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"push", "pull_request"}, w.triggers())
+
+	assert.Empty(t, dangerousWorkflowPatterns(w))
+	assert.Empty(t, workflowTokenPermissions(w))
+}
+
+func TestWorkflowTokenPermissions(t *testing.T) {
+	tests := map[string]string{
+		"on: push":                         "top-level permissions are not declared. Expected them to be read only",
+		"on: push\npermissions: write-all": "top-level permissions are write-all. Expected them to be read only",
+		"on: push\npermissions:\n  contents: write\n  issues: write\n  checks: read": "top-level permissions grant write access to contents, issues. Expected them to be read only",
+	}
+	for content, expected := range tests {
+		w, err := parseWorkflow(content)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"": expected}, workflowTokenPermissions(w))
+	}
+
+	w, err := parseWorkflow("on: push\npermissions: read-all")
+	assert.NoError(t, err)
+	assert.Empty(t, workflowTokenPermissions(w))
+}
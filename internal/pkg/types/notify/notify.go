@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/diff"
+	"gh_foundations/internal/pkg/types/report"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v2"
+)
+
+var fs = afero.NewOsFs()
+
+// A Violation of the run sent to the sinks
+type Violation struct {
+	Organization string      `json:"organization,omitempty"`
+	EntityType   string      `json:"entity_type"`
+	EntityId     string      `json:"entity_id"`
+	Violation    string      `json:"violation"`
+	Severity     string      `json:"severity"`
+	Message      string      `json:"message"`
+	Status       diff.Status `json:"status"`
+}
+
+// A Notification is the outcome of a check run pushed to the sinks
+type Notification struct {
+	Timestamp  string              `json:"rfc3339_timestamp"`
+	Summary    string              `json:"summary"`
+	Violations []Violation         `json:"violations"`
+	Reports    []types.CheckReport `json:"reports"`
+}
+
+// NewNotification lists the violations of the current reports. They are new when the previous reports did not have them.
+// Every violation is new when there are no previous reports.
+func NewNotification(previous []types.CheckReport, current []types.CheckReport, registry *types.CheckRegistry) Notification {
+	n := Notification{
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Summary:    report.Summarize(current, registry).String(),
+		Violations: []Violation{},
+		Reports:    current,
+	}
+	for _, c := range diff.Compare(previous, current).Changes {
+		if c.Status != diff.New && c.Status != diff.Persisting {
+			continue
+		}
+		severity := types.Medium
+		if rule, ok := registry.RuleForKey(c.Violation); ok {
+			severity = rule.Severity
+		}
+		n.Violations = append(n.Violations, Violation{
+			Organization: c.Organization,
+			EntityType:   c.EntityType,
+			EntityId:     c.EntityId,
+			Violation:    c.Violation,
+			Severity:     strings.ToLower(severity.String()),
+			Message:      c.Message,
+			Status:       c.Status,
+		})
+	}
+	return n
+}
+
+// OnlyNew returns the notification with the new violations only
+func (n Notification) OnlyNew() Notification {
+	violations := make([]Violation, 0, len(n.Violations))
+	for _, v := range n.Violations {
+		if v.Status == diff.New {
+			violations = append(violations, v)
+		}
+	}
+	n.Violations = violations
+	return n
+}
+
+// A Sink pushes notifications somewhere
+type Sink interface {
+	Send(n Notification) error
+}
+
+const (
+	WebhookSink = "webhook"
+	SlackSink   = "slack"
+	FileSink    = "file"
+)
+
+// SinkConfig configures a sink. Environment variables in the url and the headers are expanded so that secrets can be kept out of the file.
+type SinkConfig struct {
+	Type string `yaml:"type"`
+	// Url of the webhook and slack sinks
+	Url string `yaml:"url,omitempty"`
+	// Headers sent by the webhook sink
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// Go template rendering the JSON body of the webhook sink. The notification is sent as is when empty.
+	Template string `yaml:"template,omitempty"`
+	// Path of the file written by the file sink
+	Path string `yaml:"path,omitempty"`
+	// Only send the new violations, and skip the notification when there are none
+	OnlyNew bool `yaml:"only_new,omitempty"`
+}
+
+type Config struct {
+	Sinks []SinkConfig `yaml:"sinks"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read notification file %q: %w", path, err)
+	}
+
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse notification file %q: %w", path, err)
+	}
+	for i, s := range config.Sinks {
+		if _, err := s.NewSink(); err != nil {
+			return nil, fmt.Errorf("sink %d: %w", i, err)
+		}
+	}
+	return &config, nil
+}
+
+// NewSink creates the sink of the configuration
+func (c SinkConfig) NewSink() (Sink, error) {
+	switch c.Type {
+	case WebhookSink:
+		if c.Url == "" {
+			return nil, errors.New("a url is required")
+		}
+		headers := make(map[string]string, len(c.Headers))
+		for name, value := range c.Headers {
+			headers[name] = os.ExpandEnv(value)
+		}
+		return newWebhook(os.ExpandEnv(c.Url), headers, c.Template)
+	case SlackSink:
+		if c.Url == "" {
+			return nil, errors.New("a url is required")
+		}
+		return &Slack{Url: os.ExpandEnv(c.Url)}, nil
+	case FileSink:
+		if c.Path == "" {
+			return nil, errors.New("a path is required")
+		}
+		return &File{Path: c.Path}, nil
+	default:
+		return nil, fmt.Errorf("unsupported sink type %q. Expected one of %s, %s or %s", c.Type, WebhookSink, SlackSink, FileSink)
+	}
+}
+
+// OnlyNew reports whether a sink of the configuration only receives the new violations
+func (c *Config) OnlyNew() bool {
+	for _, s := range c.Sinks {
+		if s.OnlyNew {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify sends the notification to every sink of the configuration. Failing sinks do not prevent the others from being notified.
+func (c *Config) Notify(n Notification) error {
+	var errs error
+	for i, s := range c.Sinks {
+		sink, err := s.NewSink()
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("sink %d: %w", i, err))
+			continue
+		}
+		sent := n
+		if s.OnlyNew {
+			if sent = n.OnlyNew(); len(sent.Violations) == 0 {
+				continue
+			}
+		}
+		if err := sink.Send(sent); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("unable to notify the %s sink %d: %w", s.Type, i, err))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/diff"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry(t *testing.T) *types.CheckRegistry {
+	registry := types.NewCheckRegistry()
+	assert.NoError(t, registry.Register(types.Rule{Id: "secret_scanning", Severity: types.High, EntityType: "github_repository", Evaluate: func(any) error { return nil }}))
+	assert.NoError(t, registry.Register(types.Rule{Id: "delete_branch_on_merge", Severity: types.Low, EntityType: "github_repository", Evaluate: func(any) error { return nil }}))
+	assert.NoError(t, registry.RegisterProfile(types.GoCGuardrails, "secret_scanning", "delete_branch_on_merge"))
+	return registry
+}
+
+func newTestReport(entityId string, violations map[string]string) types.CheckReport {
+	result := types.Passed
+	if len(violations) > 0 {
+		result = types.Failed
+	}
+	return types.CheckReport{
+		EntityType:   "github_repository",
+		EntityId:     entityId,
+		Organization: "acme",
+		Results:      map[types.CheckType]types.CheckResult{types.GoCGuardrails: result},
+		Errors:       []types.CheckError{{Check: types.GoCGuardrails, Violations: violations}},
+	}
+}
+
+func newTestNotification(t *testing.T) Notification {
+	previous := []types.CheckReport{
+		newTestReport("api", map[string]string{"secret_scanning": "secret_scanning is not enabled. Expected it to be enabled"}),
+	}
+	current := []types.CheckReport{
+		newTestReport("api", map[string]string{"secret_scanning": "secret_scanning is not enabled. Expected it to be enabled"}),
+		newTestReport("web", map[string]string{"delete_branch_on_merge": "delete_branch_on_merge is false. Expected it to be true"}),
+	}
+	return NewNotification(previous, current, newTestRegistry(t))
+}
+
+// receiver records the requests of a sink
+type receiver struct {
+	bodies  []string
+	headers []http.Header
+	status  int
+}
+
+func newReceiver(t *testing.T, status int) (*receiver, *httptest.Server) {
+	r := &receiver{status: status}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.bodies = append(r.bodies, string(body))
+		r.headers = append(r.headers, req.Header)
+		w.WriteHeader(r.status)
+		fmt.Fprint(w, "received")
+	}))
+	t.Cleanup(server.Close)
+	return r, server
+}
+
+func TestNewNotification(t *testing.T) {
+	n := newTestNotification(t)
+
+	assert.Equal(t, []Violation{
+		{Organization: "acme", EntityType: "github_repository", EntityId: "api", Violation: "secret_scanning", Severity: "high", Message: "secret_scanning is not enabled. Expected it to be enabled", Status: diff.Persisting},
+		{Organization: "acme", EntityType: "github_repository", EntityId: "web", Violation: "delete_branch_on_merge", Severity: "low", Message: "delete_branch_on_merge is false. Expected it to be true", Status: diff.New},
+	}, n.Violations)
+	assert.Len(t, n.Reports, 2)
+	assert.Len(t, n.OnlyNew().Violations, 1)
+	assert.Equal(t, "web", n.OnlyNew().Violations[0].EntityId)
+}
+
+func TestWebhook_Send(t *testing.T) {
+	r, server := newReceiver(t, http.StatusOK)
+	t.Setenv("NOTIFY_TOKEN", "secret")
+	config := &Config{Sinks: []SinkConfig{{Type: WebhookSink, Url: server.URL, Headers: map[string]string{"Authorization": "Bearer ${NOTIFY_TOKEN}"}}}}
+
+	assert.NoError(t, config.Notify(newTestNotification(t)))
+
+	assert.Len(t, r.bodies, 1)
+	assert.Equal(t, "Bearer secret", r.headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", r.headers[0].Get("Content-Type"))
+	var sent Notification
+	assert.NoError(t, json.Unmarshal([]byte(r.bodies[0]), &sent))
+	assert.Len(t, sent.Violations, 2)
+	assert.Len(t, sent.Reports, 2)
+}
+
+func TestWebhook_Template(t *testing.T) {
+	r, server := newReceiver(t, http.StatusOK)
+	config := &Config{Sinks: []SinkConfig{{
+		Type:     WebhookSink,
+		Url:      server.URL,
+		Template: `{"title": {{json .Summary}}, "count": {{len .Violations}}}`,
+		OnlyNew:  true,
+	}}}
+
+	assert.NoError(t, config.Notify(newTestNotification(t)))
+
+	assert.Len(t, r.bodies, 1)
+	assert.JSONEq(t, `{"title": "Checked 2 entities: 0 passed, 2 failed, 0 errored. 2 violations (0 critical, 1 high, 0 medium, 1 low), 0 suppressed", "count": 1}`, r.bodies[0])
+}
+
+func TestWebhook_InvalidTemplate(t *testing.T) {
+	_, err := SinkConfig{Type: WebhookSink, Url: "http://localhost", Template: "{{"}.NewSink()
+
+	assert.ErrorContains(t, err, "invalid template")
+}
+
+func TestWebhook_Failure(t *testing.T) {
+	_, server := newReceiver(t, http.StatusInternalServerError)
+	config := &Config{Sinks: []SinkConfig{{Type: WebhookSink, Url: server.URL}}}
+
+	err := config.Notify(newTestNotification(t))
+
+	assert.ErrorContains(t, err, "500 Internal Server Error: received")
+}
+
+func TestSlack_Send(t *testing.T) {
+	r, server := newReceiver(t, http.StatusOK)
+	config := &Config{Sinks: []SinkConfig{{Type: SlackSink, Url: server.URL}}}
+
+	assert.NoError(t, config.Notify(newTestNotification(t)))
+
+	var payload map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(r.bodies[0]), &payload))
+	assert.Contains(t, payload["text"], "• [high] `acme/api` secret_scanning: secret_scanning is not enabled. Expected it to be enabled\n")
+	assert.Contains(t, payload["text"], "• [low] `acme/web` delete_branch_on_merge: delete_branch_on_merge is false. Expected it to be true _(new)_")
+}
+
+func TestSlack_Truncated(t *testing.T) {
+	r, server := newReceiver(t, http.StatusOK)
+	n := Notification{}
+	for i := 0; i < slackMaxViolations+5; i++ {
+		n.Violations = append(n.Violations, Violation{EntityId: fmt.Sprintf("repo-%d", i), Violation: "secret_scanning"})
+	}
+
+	assert.NoError(t, (&Slack{Url: server.URL}).Send(n))
+
+	assert.Contains(t, r.bodies[0], "…and 5 more violations")
+	assert.NotContains(t, r.bodies[0], "repo-20")
+}
+
+func TestFile_Send(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	config := &Config{Sinks: []SinkConfig{{Type: FileSink, Path: "/out/notification.json"}}}
+	fs.MkdirAll("/out", 0755)
+
+	assert.NoError(t, config.Notify(newTestNotification(t)))
+
+	data, err := afero.ReadFile(fs, "/out/notification.json")
+	assert.NoError(t, err)
+	var written Notification
+	assert.NoError(t, json.Unmarshal(data, &written))
+	assert.Len(t, written.Violations, 2)
+	exists, _ := afero.Exists(fs, "/out/.notification.json.tmp")
+	assert.False(t, exists)
+}
+
+func TestNotify_OnlyNewSkipsWithoutNewViolations(t *testing.T) {
+	r, server := newReceiver(t, http.StatusOK)
+	config := &Config{Sinks: []SinkConfig{
+		{Type: WebhookSink, Url: server.URL, OnlyNew: true},
+		{Type: SlackSink, Url: server.URL},
+	}}
+	reports := []types.CheckReport{newTestReport("api", map[string]string{"secret_scanning": "disabled"})}
+
+	assert.NoError(t, config.Notify(NewNotification(reports, reports, newTestRegistry(t))))
+
+	// Only the slack sink was notified
+	assert.Len(t, r.bodies, 1)
+	assert.Contains(t, r.bodies[0], `"text"`)
+}
+
+func TestLoadConfig(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/notify.yaml", []byte(`sinks:
+  - type: slack
+    url: https://hooks.slack.com/services/T000/B000/XXX
+    only_new: true
+  - type: file
+    path: /var/run/gh_foundations/results.json
+`), 0644)
+
+	config, err := LoadConfig("/notify.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []SinkConfig{
+		{Type: SlackSink, Url: "https://hooks.slack.com/services/T000/B000/XXX", OnlyNew: true},
+		{Type: FileSink, Path: "/var/run/gh_foundations/results.json"},
+	}, config.Sinks)
+	assert.True(t, config.OnlyNew())
+	assert.False(t, (&Config{Sinks: config.Sinks[1:]}).OnlyNew())
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/unknown.yaml", []byte("sinks:\n  - type: email\n"), 0644)
+	afero.WriteFile(fs, "/missing.yaml", []byte("sinks:\n  - type: webhook\n"), 0644)
+	afero.WriteFile(fs, "/strict.yaml", []byte("sinks:\n  - type: file\n    path: /out.json\n    url: http://localhost\n    channel: '#security'\n"), 0644)
+
+	_, err := LoadConfig("/unknown.yaml")
+	assert.ErrorContains(t, err, `sink 0: unsupported sink type "email"`)
+	_, err = LoadConfig("/missing.yaml")
+	assert.ErrorContains(t, err, "sink 0: a url is required")
+	_, err = LoadConfig("/strict.yaml")
+	assert.ErrorContains(t, err, "unable to parse notification file")
+}
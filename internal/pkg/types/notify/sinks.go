@@ -0,0 +1,138 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types/diff"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// post sends the JSON body and fails on any status other than a success
+func post(url string, headers map[string]string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s answered %s: %s", req.URL.Host, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// Webhook posts the notification as JSON, or the body rendered by its template
+type Webhook struct {
+	Url      string
+	Headers  map[string]string
+	template *template.Template
+}
+
+// Functions available to the templates of the webhooks. json encodes a value, so that strings are quoted and escaped.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+func newWebhook(url string, headers map[string]string, text string) (*Webhook, error) {
+	webhook := &Webhook{Url: url, Headers: headers}
+	if text != "" {
+		t, err := template.New("webhook").Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template: %w", err)
+		}
+		webhook.template = t
+	}
+	return webhook, nil
+}
+
+func (w *Webhook) Send(n Notification) error {
+	if w.template == nil {
+		body, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		return post(w.Url, w.Headers, body)
+	}
+
+	var body bytes.Buffer
+	if err := w.template.Execute(&body, n); err != nil {
+		return fmt.Errorf("unable to render the template: %w", err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return fmt.Errorf("the template rendered invalid JSON: %s", body.String())
+	}
+	return post(w.Url, w.Headers, body.Bytes())
+}
+
+// Most violations listed in a Slack message. Messages with more are truncated.
+const slackMaxViolations = 20
+
+// Slack posts the notification to a Slack incoming webhook
+type Slack struct {
+	Url string
+}
+
+func (s *Slack) Send(n Notification) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*GitHub Foundations check results*\n%s", n.Summary)
+	for i, v := range n.Violations {
+		if i == slackMaxViolations {
+			fmt.Fprintf(&text, "\n…and %d more violations", len(n.Violations)-slackMaxViolations)
+			break
+		}
+		entity := v.EntityId
+		if v.Organization != "" && v.Organization != v.EntityId {
+			entity = v.Organization + "/" + v.EntityId
+		}
+		fmt.Fprintf(&text, "\n• [%s] `%s` %s: %s", v.Severity, entity, v.Violation, v.Message)
+		if v.Status == diff.New {
+			text.WriteString(" _(new)_")
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{"text": text.String()})
+	if err != nil {
+		return err
+	}
+	return post(s.Url, nil, body)
+}
+
+// File writes the notification as JSON, replacing the previous one
+type File struct {
+	Path string
+}
+
+func (f *File) Send(n Notification) error {
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Readers of the drop never see a partial file
+	tmp := filepath.Join(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".tmp")
+	if err := afero.WriteFile(fs, tmp, data, 0644); err != nil {
+		return err
+	}
+	return fs.Rename(tmp, f.Path)
+}
@@ -0,0 +1,271 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
+)
+
+var fs = afero.NewOsFs()
+
+type Operator string
+
+const (
+	Equals             Operator = "equals"
+	NotEquals          Operator = "not_equals"
+	In                 Operator = "in"
+	NotIn              Operator = "not_in"
+	Contains           Operator = "contains"
+	GreaterThan        Operator = "gt"
+	GreaterThanOrEqual Operator = "gte"
+	LessThan           Operator = "lt"
+	LessThanOrEqual    Operator = "lte"
+	Exists             Operator = "exists"
+	NotExists          Operator = "not_exists"
+)
+
+// A Policy is a named set of declarative rules and expected custom repository roles.
+// Once registered the policy name is usable as a check profile.
+type Policy struct {
+	Name  string           `yaml:"name"`
+	Rules []PolicyRule     `yaml:"rules"`
+	Roles []RoleDefinition `yaml:"roles"`
+}
+
+// A PolicyRule compares a single field of an entity against an expected value.
+// Field is a gjson path into the JSON representation of the go-github struct backing the entity,
+// e.g. "security_and_analysis.secret_scanning.status" for a repository.
+type PolicyRule struct {
+	Id          string   `yaml:"id"`
+	Description string   `yaml:"description"`
+	EntityType  string   `yaml:"entity_type"`
+	Field       string   `yaml:"field"`
+	Operator    Operator `yaml:"operator"`
+	Value       any      `yaml:"value"`
+	Severity    string   `yaml:"severity"`
+	Message     string   `yaml:"message"`
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read policy file %q: %w", path, err)
+	}
+	return ParsePolicy(data)
+}
+
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return nil, fmt.Errorf("unable to parse policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (p *Policy) Validate() error {
+	var errs error
+	if p.Name == "" {
+		errs = errors.Join(errs, errors.New("policy name must not be empty"))
+	}
+	for i, rule := range p.Rules {
+		if err := rule.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("rule %d: %w", i, err))
+		}
+	}
+	for i, role := range p.Roles {
+		if err := role.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("role %d: %w", i, err))
+		}
+	}
+	return errs
+}
+
+func (r *PolicyRule) Validate() error {
+	var errs error
+	if r.Id == "" {
+		errs = errors.Join(errs, errors.New("id must not be empty"))
+	}
+	if r.EntityType == "" {
+		errs = errors.Join(errs, errors.New("entity_type must not be empty"))
+	}
+	if r.Field == "" {
+		errs = errors.Join(errs, errors.New("field must not be empty"))
+	}
+	if r.Severity != "" {
+		if _, err := types.ParseSeverity(r.Severity); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+
+	switch r.Operator {
+	case Equals, NotEquals, Contains, GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual, Exists, NotExists:
+	case In, NotIn:
+		if _, ok := normalize(r.Value).([]any); !ok {
+			errs = errors.Join(errs, fmt.Errorf("operator %q requires a list value", r.Operator))
+		}
+	default:
+		errs = errors.Join(errs, fmt.Errorf("unknown operator %q", r.Operator))
+	}
+	return errs
+}
+
+// Register adds the policy rules to the registry under a profile named after the policy.
+func (p *Policy) Register(registry *types.CheckRegistry) (types.CheckType, error) {
+	checkType := types.CheckType(p.Name)
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if err := registry.Register(rule.ToRule()); err != nil {
+			return checkType, err
+		}
+		if err := registry.RegisterProfile(checkType, rule.Id); err != nil {
+			return checkType, err
+		}
+	}
+	for i := range p.Roles {
+		role := &p.Roles[i]
+		if err := registry.Register(role.ToRule()); err != nil {
+			return checkType, err
+		}
+		if err := registry.RegisterProfile(checkType, role.Id()); err != nil {
+			return checkType, err
+		}
+	}
+	return checkType, nil
+}
+
+func (r *PolicyRule) ToRule() types.Rule {
+	severity, _ := types.ParseSeverity(r.Severity)
+	if r.Severity == "" {
+		severity = types.Medium
+	}
+
+	description := r.Description
+	if description == "" {
+		description = fmt.Sprintf("%s %s %s", r.Field, r.Operator, r.expected().Raw)
+	}
+
+	return types.Rule{
+		Id:          r.Id,
+		Description: description,
+		Severity:    severity,
+		EntityType:  r.EntityType,
+		Evaluate:    r.Evaluate,
+	}
+}
+
+func (r *PolicyRule) Evaluate(entity any) error {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return types.EvaluationErrorf("unable to read %s: %w", r.Field, err)
+	}
+	actual := gjson.GetBytes(data, r.Field)
+
+	compliant, err := r.compare(actual, r.expected())
+	if err != nil {
+		return &types.EvaluationError{Err: err}
+	}
+	if compliant {
+		return nil
+	}
+
+	if r.Message != "" {
+		return errors.New(r.Message)
+	}
+	got := "unset"
+	if actual.Exists() {
+		got = actual.Raw
+	}
+	return fmt.Errorf("%s is %s. Expected it to be %s %s", r.Field, got, r.Operator, r.expected().Raw)
+}
+
+func (r *PolicyRule) expected() gjson.Result {
+	data, err := json.Marshal(normalize(r.Value))
+	if err != nil {
+		return gjson.Result{}
+	}
+	return gjson.ParseBytes(data)
+}
+
+func (r *PolicyRule) compare(actual gjson.Result, expected gjson.Result) (bool, error) {
+	switch r.Operator {
+	case Equals:
+		return equal(actual, expected), nil
+	case NotEquals:
+		return !equal(actual, expected), nil
+	case In, NotIn:
+		found := false
+		for _, e := range expected.Array() {
+			if equal(actual, e) {
+				found = true
+				break
+			}
+		}
+		return found == (r.Operator == In), nil
+	case Contains:
+		if actual.IsArray() {
+			for _, a := range actual.Array() {
+				if equal(a, expected) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return actual.Type == gjson.String && strings.Contains(actual.Str, expected.String()), nil
+	case GreaterThan, GreaterThanOrEqual, LessThan, LessThanOrEqual:
+		if expected.Type != gjson.Number {
+			return false, fmt.Errorf("operator %q requires a numeric value", r.Operator)
+		}
+		if actual.Type != gjson.Number {
+			return false, nil
+		}
+		switch r.Operator {
+		case GreaterThan:
+			return actual.Num > expected.Num, nil
+		case GreaterThanOrEqual:
+			return actual.Num >= expected.Num, nil
+		case LessThan:
+			return actual.Num < expected.Num, nil
+		default:
+			return actual.Num <= expected.Num, nil
+		}
+	case Exists:
+		return actual.Exists() && actual.Type != gjson.Null, nil
+	case NotExists:
+		return !actual.Exists() || actual.Type == gjson.Null, nil
+	default:
+		return false, fmt.Errorf("unknown operator %q", r.Operator)
+	}
+}
+
+func equal(a gjson.Result, b gjson.Result) bool {
+	return reflect.DeepEqual(a.Value(), b.Value())
+}
+
+// yaml.v2 decodes mappings as map[interface{}]interface{} which encoding/json can not marshal
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = normalize(val)
+		}
+		return m
+	case []any:
+		for i, val := range v {
+			v[i] = normalize(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"gh_foundations/internal/pkg/types"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus struct {
+	Status string `json:"status"`
+}
+
+type testEntity struct {
+	Name          string      `json:"name"`
+	Archived      bool        `json:"archived"`
+	Topics        []string    `json:"topics"`
+	Reviewers     int         `json:"reviewers"`
+	SecretScannig *testStatus `json:"secret_scanning,omitempty"`
+}
+
+const testPolicy = `
+name: TestPolicy
+rules:
+  - id: secret_scanning_enabled
+    entity_type: test_entity
+    field: secret_scanning.status
+    operator: equals
+    value: enabled
+    severity: high
+  - id: not_archived
+    entity_type: test_entity
+    field: archived
+    operator: equals
+    value: false
+    message: repository must not be archived
+  - id: enough_reviewers
+    entity_type: test_entity
+    field: reviewers
+    operator: gte
+    value: 2
+  - id: has_topic
+    entity_type: test_entity
+    field: topics
+    operator: contains
+    value: compliance
+  - id: known_name
+    entity_type: test_entity
+    field: name
+    operator: in
+    value: [docs, api]
+`
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TestPolicy", policy.Name)
+	assert.Len(t, policy.Rules, 5)
+	assert.Equal(t, types.High, policy.Rules[0].ToRule().Severity)
+	assert.Equal(t, types.Medium, policy.Rules[1].ToRule().Severity)
+}
+
+func TestParsePolicy_InvalidRules(t *testing.T) {
+	_, err := ParsePolicy([]byte(`
+name: Invalid
+rules:
+  - id: bad_operator
+    entity_type: test_entity
+    field: name
+    operator: approximately
+  - id: bad_in
+    entity_type: test_entity
+    field: name
+    operator: in
+    value: docs
+`))
+
+	assert.ErrorContains(t, err, `unknown operator "approximately"`)
+	assert.ErrorContains(t, err, `operator "in" requires a list value`)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/policy.yaml", []byte(testPolicy), 0644)
+
+	policy, err := LoadPolicy("/policy.yaml")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "TestPolicy", policy.Name)
+}
+
+func TestPolicy_RegisterAndRun(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testPolicy))
+	assert.NoError(t, err)
+
+	registry := types.NewCheckRegistry()
+	checkType, err := policy.Register(registry)
+	assert.NoError(t, err)
+	assert.Equal(t, types.CheckType("TestPolicy"), checkType)
+
+	report := types.CheckReport{
+		EntityType: "test_entity",
+		Results:    make(map[types.CheckType]types.CheckResult),
+	}
+	registry.Run(&testEntity{Name: "website", Archived: true, Reviewers: 1, Topics: []string{"compliance"}}, []types.CheckType{checkType}, &report)
+
+	assert.Equal(t, types.Failed, report.Results[checkType])
+	assert.Equal(t, map[string]string{
+		"secret_scanning_enabled": "secret_scanning.status is unset. Expected it to be equals \"enabled\"",
+		"not_archived":            "repository must not be archived",
+		"enough_reviewers":        "reviewers is 1. Expected it to be gte 2",
+		"known_name":              "name is \"website\". Expected it to be in [\"docs\",\"api\"]",
+	}, report.Errors[0].Violations)
+}
+
+func TestPolicy_RunPassed(t *testing.T) {
+	policy, _ := ParsePolicy([]byte(testPolicy))
+	registry := types.NewCheckRegistry()
+	checkType, _ := policy.Register(registry)
+
+	report := types.CheckReport{
+		EntityType: "test_entity",
+		Results:    make(map[types.CheckType]types.CheckResult),
+	}
+	entity := &testEntity{
+		Name:          "docs",
+		Reviewers:     2,
+		Topics:        []string{"docs", "compliance"},
+		SecretScannig: &testStatus{Status: "enabled"},
+	}
+	registry.Run(entity, []types.CheckType{checkType}, &report)
+
+	assert.Equal(t, types.Passed, report.Results[checkType])
+}
@@ -0,0 +1,123 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/go-github/v61/github"
+)
+
+// The entity type of the organizations role definitions are evaluated against
+const roleEntityType = "github_organization"
+
+var baseRoles = []string{"read", "triage", "write", "maintain"}
+
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9]+`)
+
+// A RoleDefinition declares a custom repository role the organization is expected to define,
+// with exactly the given base role and permissions.
+type RoleDefinition struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	BaseRole    string   `yaml:"base_role"`
+	Permissions []string `yaml:"permissions"`
+	Severity    string   `yaml:"severity"`
+}
+
+// Entities exposing the custom repository roles of an organization
+type customRoleProvider interface {
+	CustomRepositoryRoles() ([]github.CustomRepoRoles, error)
+}
+
+func (d *RoleDefinition) Validate() error {
+	var errs error
+	if d.Name == "" {
+		errs = errors.Join(errs, errors.New("name must not be empty"))
+	}
+	if !slices.Contains(baseRoles, d.BaseRole) {
+		errs = errors.Join(errs, fmt.Errorf("base_role must be one of %s", strings.Join(baseRoles, ", ")))
+	}
+	if d.Severity != "" {
+		if _, err := types.ParseSeverity(d.Severity); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// Id derives the rule id from the role name, e.g. "custom_repository_role.security_engineer" for "Security Engineer"
+func (d *RoleDefinition) Id() string {
+	name := strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(d.Name), "_"), "_")
+	return "custom_repository_role." + name
+}
+
+func (d *RoleDefinition) ToRule() types.Rule {
+	severity, _ := types.ParseSeverity(d.Severity)
+	if d.Severity == "" {
+		severity = types.Medium
+	}
+
+	description := d.Description
+	if description == "" {
+		description = fmt.Sprintf("The %s custom repository role is defined with the %s base role and the expected permissions", d.Name, d.BaseRole)
+	}
+
+	return types.Rule{
+		Id:          d.Id(),
+		Description: description,
+		Severity:    severity,
+		EntityType:  roleEntityType,
+		Evaluate:    d.Evaluate,
+	}
+}
+
+// Evaluate reports a missing role, a different base role, and the permissions missing from or added to the role
+func (d *RoleDefinition) Evaluate(entity any) error {
+	provider, ok := entity.(customRoleProvider)
+	if !ok {
+		return fmt.Errorf("%T does not have custom repository roles", entity)
+	}
+
+	var role *github.CustomRepoRoles
+	roles, err := provider.CustomRepositoryRoles()
+	if err != nil {
+		return err
+	}
+	for i := range roles {
+		if strings.EqualFold(roles[i].GetName(), d.Name) {
+			role = &roles[i]
+			break
+		}
+	}
+	if role == nil {
+		return fmt.Errorf("custom repository role %q is not defined", d.Name)
+	}
+
+	var errs error
+	if role.GetBaseRole() != d.BaseRole {
+		errs = errors.Join(errs, fmt.Errorf("custom repository role %q has the %s base role. Expected it to be %s", d.Name, role.GetBaseRole(), d.BaseRole))
+	}
+	if missing := difference(d.Permissions, role.Permissions); len(missing) > 0 {
+		errs = errors.Join(errs, fmt.Errorf("custom repository role %q is missing permissions %s", d.Name, strings.Join(missing, ", ")))
+	}
+	if extra := difference(role.Permissions, d.Permissions); len(extra) > 0 {
+		errs = errors.Join(errs, fmt.Errorf("custom repository role %q has unexpected permissions %s", d.Name, strings.Join(extra, ", ")))
+	}
+	return errs
+}
+
+// difference returns the sorted values of a that are not in b
+func difference(a []string, b []string) []string {
+	var diff []string
+	for _, v := range a {
+		if !slices.Contains(b, v) && !slices.Contains(diff, v) {
+			diff = append(diff, v)
+		}
+	}
+	slices.Sort(diff)
+	return diff
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"gh_foundations/internal/pkg/types"
+	"testing"
+
+	"github.com/google/go-github/v61/github"
+	"github.com/stretchr/testify/assert"
+)
+
+type testOrganization struct {
+	roles []github.CustomRepoRoles
+	err   error
+}
+
+func (o *testOrganization) CustomRepositoryRoles() ([]github.CustomRepoRoles, error) {
+	return o.roles, o.err
+}
+
+const testRolePolicy = `
+name: Roles
+roles:
+  - name: Security Engineer
+    base_role: maintain
+    permissions: [write_code_scanning, delete_alerts_code_scanning]
+    severity: high
+  - name: Contractor
+    base_role: write
+    permissions: [manage_webhooks]
+`
+
+func TestRoleDefinition_Id(t *testing.T) {
+	role := RoleDefinition{Name: "Security Engineer (GHAS)"}
+
+	assert.Equal(t, "custom_repository_role.security_engineer_ghas", role.Id())
+}
+
+func TestParsePolicy_InvalidRoles(t *testing.T) {
+	_, err := ParsePolicy([]byte(`
+name: Roles
+roles:
+  - base_role: admin
+    severity: urgent
+`))
+
+	assert.ErrorContains(t, err, "role 0: name must not be empty")
+	assert.ErrorContains(t, err, "base_role must be one of read, triage, write, maintain")
+	assert.ErrorContains(t, err, "unknown severity")
+}
+
+func TestPolicy_RegisterAndRunRoles(t *testing.T) {
+	policy, err := ParsePolicy([]byte(testRolePolicy))
+	assert.NoError(t, err)
+
+	registry := types.NewCheckRegistry()
+	checkType, err := policy.Register(registry)
+	assert.NoError(t, err)
+	rule, _ := registry.Rule("custom_repository_role.security_engineer")
+	assert.Equal(t, types.High, rule.Severity)
+
+	report := types.CheckReport{
+		EntityType: roleEntityType,
+		Results:    make(map[types.CheckType]types.CheckResult),
+	}
+	org := &testOrganization{roles: []github.CustomRepoRoles{
+		{
+			Name:        github.String("security engineer"),
+			BaseRole:    github.String("write"),
+			Permissions: []string{"write_code_scanning", "manage_webhooks", "create_tag"},
+		},
+	}}
+	registry.Run(org, []types.CheckType{checkType}, &report)
+
+	assert.Equal(t, types.Failed, report.Results[checkType])
+	assert.Equal(t, map[string]string{
+		"custom_repository_role.security_engineer": "custom repository role \"Security Engineer\" has the write base role. Expected it to be maintain\n" +
+			"custom repository role \"Security Engineer\" is missing permissions delete_alerts_code_scanning\n" +
+			"custom repository role \"Security Engineer\" has unexpected permissions create_tag, manage_webhooks",
+		"custom_repository_role.contractor": "custom repository role \"Contractor\" is not defined",
+	}, report.Errors[0].Violations)
+}
+
+func TestPolicy_RunRolesPassed(t *testing.T) {
+	policy, _ := ParsePolicy([]byte(testRolePolicy))
+	registry := types.NewCheckRegistry()
+	checkType, _ := policy.Register(registry)
+
+	report := types.CheckReport{
+		EntityType: roleEntityType,
+		Results:    make(map[types.CheckType]types.CheckResult),
+	}
+	org := &testOrganization{roles: []github.CustomRepoRoles{
+		{Name: github.String("Contractor"), BaseRole: github.String("write"), Permissions: []string{"manage_webhooks"}},
+		{Name: github.String("Security Engineer"), BaseRole: github.String("maintain"), Permissions: []string{"write_code_scanning", "delete_alerts_code_scanning"}},
+	}}
+	registry.Run(org, []types.CheckType{checkType}, &report)
+
+	assert.Equal(t, types.Passed, report.Results[checkType])
+}
+
+func TestPolicy_RunRolesUnavailable(t *testing.T) {
+	policy, _ := ParsePolicy([]byte(testRolePolicy))
+	registry := types.NewCheckRegistry()
+	checkType, _ := policy.Register(registry)
+
+	report := types.CheckReport{
+		EntityType: roleEntityType,
+		Results:    make(map[types.CheckType]types.CheckResult),
+	}
+	org := &testOrganization{err: types.EvaluationErrorf("unable to list the custom repository roles: 404 Not Found")}
+	registry.Run(org, []types.CheckType{checkType}, &report)
+
+	assert.Equal(t, types.Errored, report.Results[checkType])
+	assert.Empty(t, report.Errors[0].Violations)
+	assert.Len(t, report.Errors[0].Unevaluated, 2)
+}
@@ -0,0 +1,373 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+type Severity uint16
+
+const (
+	Low Severity = iota
+	Medium
+	High
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Low:
+		return "Low"
+	case Medium:
+		return "Medium"
+	case High:
+		return "High"
+	case Critical:
+		return "Critical"
+	default:
+		return "Unknown"
+	}
+}
+
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// ParseSeverity converts a case insensitive severity name into a Severity
+func ParseSeverity(name string) (Severity, error) {
+	switch strings.ToLower(name) {
+	case "low":
+		return Low, nil
+	case "medium":
+		return Medium, nil
+	case "high":
+		return High, nil
+	case "critical":
+		return Critical, nil
+	default:
+		return Low, fmt.Errorf("unknown severity %q", name)
+	}
+}
+
+// A Rule is a single named check evaluated against one type of entity.
+// Evaluate returns a non nil error describing the violation when the entity is not compliant.
+type Rule struct {
+	Id          string
+	Description string
+	Severity    Severity
+	EntityType  string
+	Evaluate    func(entity any) error
+}
+
+// A Control is an item of an external benchmark, e.g. a CIS control or an OpenSSF Scorecard check,
+// that a rule of a profile provides evidence for
+type Control struct {
+	Id  string `json:"id"`
+	Url string `json:"url,omitempty"`
+}
+
+// EvaluateAs adapts a typed evaluation function to the signature expected by Rule.Evaluate
+func EvaluateAs[T any](fn func(entity T) error) func(entity any) error {
+	return func(entity any) error {
+		e, ok := entity.(T)
+		if !ok {
+			return fmt.Errorf("unexpected entity type %T", entity)
+		}
+		return fn(e)
+	}
+}
+
+// Violations lets a rule report several independent violations, keyed by what is not compliant.
+// Each violation is recorded under "<rule id>.<key>" so that it can be reported and suppressed on its own.
+type Violations map[string]string
+
+func (v Violations) Error() string {
+	keys := make([]string, 0, len(v))
+	for key := range v {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	messages := make([]string, 0, len(keys))
+	for _, key := range keys {
+		messages = append(messages, v[key])
+	}
+	return strings.Join(messages, "\n")
+}
+
+// An EvaluationError reports that a rule could not be evaluated, e.g. because the data it reads could not be fetched.
+// The check is then recorded as errored instead of failed, as nothing is known about the compliance of the entity.
+type EvaluationError struct {
+	Err error
+}
+
+// EvaluationErrorf formats an EvaluationError
+func EvaluationErrorf(format string, a ...any) error {
+	return &EvaluationError{Err: fmt.Errorf(format, a...)}
+}
+
+func (e *EvaluationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *EvaluationError) Unwrap() error {
+	return e.Err
+}
+
+// CheckRegistry holds every known rule and the profiles (check types) that select them.
+type CheckRegistry struct {
+	rules    map[string]Rule
+	order    []string
+	profiles map[CheckType][]string
+	controls map[CheckType]map[string][]Control
+	disabled map[string]bool
+}
+
+func NewCheckRegistry() *CheckRegistry {
+	return &CheckRegistry{
+		rules:    make(map[string]Rule),
+		profiles: make(map[CheckType][]string),
+		controls: make(map[CheckType]map[string][]Control),
+		disabled: make(map[string]bool),
+	}
+}
+
+// The registry used by the check command. Entities register their rules against it in their init functions.
+var DefaultRegistry = NewCheckRegistry()
+
+// Clone returns a copy of the registry. Registering rules, profiles or controls in the copy or disabling its
+// rules leaves the original unchanged.
+func (r *CheckRegistry) Clone() *CheckRegistry {
+	clone := NewCheckRegistry()
+	for id, rule := range r.rules {
+		clone.rules[id] = rule
+	}
+	clone.order = slices.Clone(r.order)
+	for checkType, ruleIds := range r.profiles {
+		clone.profiles[checkType] = slices.Clone(ruleIds)
+	}
+	for checkType, rules := range r.controls {
+		clone.controls[checkType] = make(map[string][]Control)
+		for id, controls := range rules {
+			clone.controls[checkType][id] = slices.Clone(controls)
+		}
+	}
+	for id, disabled := range r.disabled {
+		clone.disabled[id] = disabled
+	}
+	return clone
+}
+
+func (r *CheckRegistry) Register(rules ...Rule) error {
+	for _, rule := range rules {
+		if rule.Id == "" {
+			return errors.New("rule id must not be empty")
+		}
+		if rule.Evaluate == nil {
+			return fmt.Errorf("rule %q has no evaluate function", rule.Id)
+		}
+		if _, exists := r.rules[rule.Id]; exists {
+			return fmt.Errorf("rule %q is already registered", rule.Id)
+		}
+		r.rules[rule.Id] = rule
+		r.order = append(r.order, rule.Id)
+	}
+	return nil
+}
+
+// RegisterProfile adds the given rule ids to a profile, creating the profile if it does not exist.
+func (r *CheckRegistry) RegisterProfile(checkType CheckType, ruleIds ...string) error {
+	for _, id := range ruleIds {
+		if _, exists := r.rules[id]; !exists {
+			return fmt.Errorf("profile %q references unknown rule %q", checkType, id)
+		}
+		if !slices.Contains(r.profiles[checkType], id) {
+			r.profiles[checkType] = append(r.profiles[checkType], id)
+		}
+	}
+	return nil
+}
+
+// MapControls records the benchmark controls a rule of the profile provides evidence for
+func (r *CheckRegistry) MapControls(checkType CheckType, ruleId string, controls ...Control) error {
+	if !slices.Contains(r.profiles[checkType], ruleId) {
+		return fmt.Errorf("profile %q does not include rule %q", checkType, ruleId)
+	}
+	if r.controls[checkType] == nil {
+		r.controls[checkType] = make(map[string][]Control)
+	}
+	r.controls[checkType][ruleId] = append(r.controls[checkType][ruleId], controls...)
+	return nil
+}
+
+// Controls returns the benchmark controls of the rule that recorded the violation key in the profile
+func (r *CheckRegistry) Controls(checkType CheckType, key string) []Control {
+	rule, ok := r.RuleForKey(key)
+	if !ok {
+		return nil
+	}
+	return r.controls[checkType][rule.Id]
+}
+
+// Disable prevents the given rules from being evaluated by any profile.
+func (r *CheckRegistry) Disable(ruleIds ...string) error {
+	for _, id := range ruleIds {
+		if _, exists := r.rules[id]; !exists {
+			return fmt.Errorf("unknown rule %q", id)
+		}
+		r.disabled[id] = true
+	}
+	return nil
+}
+
+func (r *CheckRegistry) Rule(id string) (Rule, bool) {
+	rule, ok := r.rules[id]
+	return rule, ok
+}
+
+// RuleForKey returns the rule that recorded a violation key. Keys of the violations reported through
+// Violations resolve to the rule that reported them.
+func (r *CheckRegistry) RuleForKey(key string) (Rule, bool) {
+	for {
+		if rule, ok := r.rules[key]; ok {
+			return rule, true
+		}
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			return Rule{}, false
+		}
+		key = key[:i]
+	}
+}
+
+// Rules returns every registered rule in registration order.
+func (r *CheckRegistry) Rules() []Rule {
+	rules := make([]Rule, 0, len(r.order))
+	for _, id := range r.order {
+		rules = append(rules, r.rules[id])
+	}
+	return rules
+}
+
+// Profiles returns the names of every registered profile sorted alphabetically.
+func (r *CheckRegistry) Profiles() []CheckType {
+	profiles := make([]CheckType, 0, len(r.profiles))
+	for p := range r.profiles {
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i] < profiles[j] })
+	return profiles
+}
+
+// ProfilesFor returns the profiles that include the given rule.
+func (r *CheckRegistry) ProfilesFor(ruleId string) []CheckType {
+	var profiles []CheckType
+	for _, p := range r.Profiles() {
+		if slices.Contains(r.profiles[p], ruleId) {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+func (r *CheckRegistry) HasProfile(checkType CheckType) bool {
+	_, ok := r.profiles[checkType]
+	return ok
+}
+
+// RulesFor returns the enabled rules of a profile that apply to the given entity type.
+func (r *CheckRegistry) RulesFor(checkType CheckType, entityType string) []Rule {
+	var rules []Rule
+	for _, id := range r.profiles[checkType] {
+		rule := r.rules[id]
+		if rule.EntityType == entityType && !r.disabled[id] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// disabledRulesFor returns the disabled rules of a profile that apply to the given entity type.
+func (r *CheckRegistry) disabledRulesFor(checkType CheckType, entityType string) []string {
+	var rules []string
+	for _, id := range r.profiles[checkType] {
+		if r.rules[id].EntityType == entityType && r.disabled[id] {
+			rules = append(rules, id)
+		}
+	}
+	return rules
+}
+
+// Run evaluates the rules of each check type against the entity and records the outcome in the report.
+// Check types with no rules applicable to the report's entity type are marked as not applicable.
+// Disabled rules that would have been evaluated are recorded so that they are not mistaken for passing ones.
+// A check type with a rule returning an EvaluationError is errored, even when its other rules found violations.
+// Rules that could only read part of the entity join the EvaluationError with the Violations of the rest.
+func (r *CheckRegistry) Run(entity any, checkTypes []CheckType, report *CheckReport) {
+	for _, t := range checkTypes {
+		for _, id := range r.disabledRulesFor(t, report.EntityType) {
+			if !slices.Contains(report.Disabled, id) {
+				report.Disabled = append(report.Disabled, id)
+			}
+		}
+		rules := r.RulesFor(t, report.EntityType)
+		if len(rules) == 0 {
+			report.Results[t] = NotApplicable
+			continue
+		}
+
+		var allErrors error
+		violations := make(map[string]string)
+		var unevaluated map[string]string
+		for _, rule := range rules {
+			err := rule.Evaluate(entity)
+			if err == nil {
+				continue
+			}
+			allErrors = errors.Join(allErrors, err)
+			var evaluationError *EvaluationError
+			var ruleViolations Violations
+			if errors.As(err, &evaluationError) {
+				if unevaluated == nil {
+					unevaluated = make(map[string]string)
+				}
+				unevaluated[rule.Id] = evaluationError.Error()
+			}
+			if errors.As(err, &ruleViolations) && len(ruleViolations) > 0 {
+				for key, message := range ruleViolations {
+					violations[rule.Id+"."+key] = message
+				}
+			} else if evaluationError == nil {
+				violations[rule.Id] = err.Error()
+			}
+		}
+
+		if allErrors != nil {
+			var controls map[string][]Control
+			for key := range violations {
+				if c := r.Controls(t, key); len(c) > 0 {
+					if controls == nil {
+						controls = make(map[string][]Control)
+					}
+					controls[key] = c
+				}
+			}
+			report.Results[t] = Failed
+			if unevaluated != nil {
+				report.Results[t] = Errored
+			}
+			report.Errors = append(report.Errors, CheckError{
+				Err:         allErrors,
+				Check:       t,
+				Violations:  violations,
+				Unevaluated: unevaluated,
+				Controls:    controls,
+			})
+		} else {
+			report.Results[t] = Passed
+		}
+	}
+}
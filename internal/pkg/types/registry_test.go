@@ -0,0 +1,180 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testEntity struct {
+	enabled bool
+}
+
+func newTestRegistry() *CheckRegistry {
+	registry := NewCheckRegistry()
+	registry.Register(
+		Rule{
+			Id:         "enabled",
+			Severity:   High,
+			EntityType: "test_entity",
+			Evaluate: EvaluateAs(func(e *testEntity) error {
+				if !e.enabled {
+					return errors.New("enabled is false. Expected it to be true")
+				}
+				return nil
+			}),
+		},
+		Rule{
+			Id:         "always_passes",
+			Severity:   Low,
+			EntityType: "test_entity",
+			Evaluate:   func(entity any) error { return nil },
+		},
+	)
+	registry.RegisterProfile("TestProfile", "enabled", "always_passes")
+	return registry
+}
+
+func newTestReport() CheckReport {
+	return CheckReport{
+		EntityType: "test_entity",
+		EntityId:   "test",
+		Results:    make(map[CheckType]CheckResult),
+		Errors:     []CheckError{},
+	}
+}
+
+func TestCheckRegistry_RegisterDuplicate(t *testing.T) {
+	registry := newTestRegistry()
+
+	err := registry.Register(Rule{Id: "enabled", Evaluate: func(entity any) error { return nil }})
+
+	assert.Error(t, err)
+}
+
+func TestCheckRegistry_RegisterProfileUnknownRule(t *testing.T) {
+	registry := newTestRegistry()
+
+	err := registry.RegisterProfile("TestProfile", "unknown")
+
+	assert.Error(t, err)
+}
+
+func TestCheckRegistry_RunFailed(t *testing.T) {
+	registry := newTestRegistry()
+	report := newTestReport()
+
+	registry.Run(&testEntity{enabled: false}, []CheckType{"TestProfile"}, &report)
+
+	assert.Equal(t, Failed, report.Results["TestProfile"])
+	assert.Len(t, report.Errors, 1)
+	assert.Equal(t, map[string]string{"enabled": "enabled is false. Expected it to be true"}, report.Errors[0].Violations)
+}
+
+func TestCheckRegistry_RunPassed(t *testing.T) {
+	registry := newTestRegistry()
+	report := newTestReport()
+
+	registry.Run(&testEntity{enabled: true}, []CheckType{"TestProfile"}, &report)
+
+	assert.Equal(t, Passed, report.Results["TestProfile"])
+	assert.Empty(t, report.Errors)
+}
+
+func TestCheckRegistry_RunDisabledRule(t *testing.T) {
+	registry := newTestRegistry()
+	report := newTestReport()
+
+	assert.NoError(t, registry.Disable("enabled"))
+	registry.Run(&testEntity{enabled: false}, []CheckType{"TestProfile"}, &report)
+
+	assert.Equal(t, Passed, report.Results["TestProfile"])
+	assert.Equal(t, []string{"enabled"}, report.Disabled)
+}
+
+func TestCheckRegistry_Clone(t *testing.T) {
+	registry := newTestRegistry()
+
+	clone := registry.Clone()
+	assert.NoError(t, clone.Disable("enabled"))
+	assert.NoError(t, clone.RegisterProfile(CustomRules, "always_passes"))
+
+	assert.Len(t, registry.RulesFor("TestProfile", "test_entity"), 2)
+	assert.False(t, registry.HasProfile(CustomRules))
+	assert.Len(t, clone.RulesFor("TestProfile", "test_entity"), 1)
+	assert.True(t, clone.HasProfile(CustomRules))
+}
+
+func TestCheckRegistry_RunUnknownProfile(t *testing.T) {
+	registry := newTestRegistry()
+	report := newTestReport()
+
+	registry.Run(&testEntity{}, []CheckType{"Unknown"}, &report)
+
+	assert.Equal(t, NotApplicable, report.Results["Unknown"])
+}
+
+func TestCheckRegistry_RunViolations(t *testing.T) {
+	registry := NewCheckRegistry()
+	registry.Register(Rule{
+		Id:         "settings",
+		Severity:   Critical,
+		EntityType: "test_entity",
+		Evaluate: func(entity any) error {
+			return Violations{"a": "a is unset", "b": "b is unset"}
+		},
+	})
+	registry.RegisterProfile("TestProfile", "settings")
+	report := newTestReport()
+
+	registry.Run(&testEntity{}, []CheckType{"TestProfile"}, &report)
+
+	assert.Equal(t, Failed, report.Results["TestProfile"])
+	assert.Equal(t, map[string]string{"settings.a": "a is unset", "settings.b": "b is unset"}, report.Errors[0].Violations)
+	rule, ok := registry.RuleForKey("settings.a")
+	assert.True(t, ok)
+	assert.Equal(t, Critical, rule.Severity)
+	_, ok = registry.RuleForKey("unknown.a")
+	assert.False(t, ok)
+}
+
+func TestCheckRegistry_RunEvaluationError(t *testing.T) {
+	registry := newTestRegistry()
+	registry.Register(Rule{
+		Id:         "unreadable",
+		EntityType: "test_entity",
+		Evaluate: func(entity any) error {
+			return EvaluationErrorf("unable to read the settings: %w", errors.New("403 Forbidden"))
+		},
+	})
+	registry.RegisterProfile("TestProfile", "unreadable")
+	report := newTestReport()
+
+	registry.Run(&testEntity{enabled: false}, []CheckType{"TestProfile"}, &report)
+
+	assert.Equal(t, Errored, report.Results["TestProfile"])
+	assert.Equal(t, map[string]string{"enabled": "enabled is false. Expected it to be true"}, report.Errors[0].Violations)
+	assert.Equal(t, map[string]string{"unreadable": "unable to read the settings: 403 Forbidden"}, report.Errors[0].Unevaluated)
+}
+
+func TestCheckRegistry_RunControls(t *testing.T) {
+	registry := newTestRegistry()
+	control := Control{Id: "CIS 1.1.1", Url: "https://example.com/cis"}
+	assert.NoError(t, registry.MapControls("TestProfile", "enabled", control))
+	report := newTestReport()
+
+	registry.Run(&testEntity{enabled: false}, []CheckType{"TestProfile", "OtherProfile"}, &report)
+
+	assert.Equal(t, map[string][]Control{"enabled": {control}}, report.Errors[0].Controls)
+	assert.Equal(t, []Control{control}, registry.Controls("TestProfile", "enabled"))
+	assert.Empty(t, registry.Controls("OtherProfile", "enabled"))
+}
+
+func TestCheckRegistry_MapControlsOutsideProfile(t *testing.T) {
+	registry := newTestRegistry()
+
+	err := registry.MapControls("OtherProfile", "enabled", Control{Id: "CIS 1.1.1"})
+
+	assert.Error(t, err)
+}
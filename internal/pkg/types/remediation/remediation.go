@@ -0,0 +1,442 @@
+package remediation
+
+import (
+	"bytes"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/github"
+	githubfoundations "gh_foundations/internal/pkg/types/github_foundations"
+	"gh_foundations/internal/pkg/types/status"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var fs = afero.NewOsFs()
+
+// An inputFix sets a repository input of the foundations module to the value complying with a rule
+type inputFix struct {
+	input string
+	// unfixable returns why the compliant value of the input cannot be computed for the repository, if it cannot. May be nil.
+	unfixable func(repo *githubfoundations.RepositoryInput) string
+	// value returns the compliant value of the input, or false when the input already complies
+	value func(repo *githubfoundations.RepositoryInput) (cty.Value, bool)
+}
+
+func enable(input string, enabled func(repo *githubfoundations.RepositoryInput) bool) inputFix {
+	return inputFix{
+		input: input,
+		value: func(repo *githubfoundations.RepositoryInput) (cty.Value, bool) {
+			return cty.True, !enabled(repo)
+		},
+	}
+}
+
+// Inputs fixing the violations of the repository rules, by rule id
+var repositoryFixes = map[string]inputFix{
+	"delete_branch_on_merge": enable("delete_head_on_merge", func(repo *githubfoundations.RepositoryInput) bool {
+		return repo.DeleteHeadBranchOnMerge
+	}),
+	"dependabot_security_updates": enable("dependabot_security_updates", func(repo *githubfoundations.RepositoryInput) bool {
+		return repo.DependabotSecurityUpdates
+	}),
+	// Advanced security enables secret scanning and its push protection
+	"secret_scanning": enable("advance_security", func(repo *githubfoundations.RepositoryInput) bool {
+		return repo.AdvanceSecurity
+	}),
+	"secret_scanning_push_protection": enable("advance_security", func(repo *githubfoundations.RepositoryInput) bool {
+		return repo.AdvanceSecurity
+	}),
+	// The module protects the protected_branches with a pull request ruleset
+	"rulesets": {
+		input: "protected_branches",
+		unfixable: func(repo *githubfoundations.RepositoryInput) string {
+			if repo.DefaultBranch == "" {
+				return "the repository has no default_branch input to protect"
+			}
+			return ""
+		},
+		value: func(repo *githubfoundations.RepositoryInput) (cty.Value, bool) {
+			if slices.Contains(repo.ProtectedBranches, repo.DefaultBranch) {
+				return cty.NilVal, false
+			}
+			branches := make([]cty.Value, 0, len(repo.ProtectedBranches)+1)
+			for _, branch := range append(slices.Clone(repo.ProtectedBranches), repo.DefaultBranch) {
+				branches = append(branches, cty.StringVal(branch))
+			}
+			return cty.ListVal(branches), true
+		},
+	},
+}
+
+// A Change sets an input of a repository managed in the projects directory
+type Change struct {
+	Organization string `json:"organization"`
+	Repository   string `json:"repository"`
+	Path         string `json:"path"`
+	Input        string `json:"input"`
+	// The compliant value, in HCL
+	Value string `json:"value"`
+	// Violation keys fixed by the change
+	Violations []string `json:"violations"`
+}
+
+// An Unfixable violation cannot be fixed through the inputs of the foundations module
+type Unfixable struct {
+	Organization string `json:"organization,omitempty"`
+	EntityType   string `json:"entity_type"`
+	EntityId     string `json:"entity_id"`
+	Violation    string `json:"violation"`
+	Reason       string `json:"reason"`
+}
+
+// A Plan lists the changes to the HCL inputs fixing the violations of a run, and the violations they cannot fix
+type Plan struct {
+	Changes   []Change    `json:"changes"`
+	Unfixable []Unfixable `json:"unfixable"`
+	// HCL files read by the plan and their edits, by path
+	files map[string]*hclFile
+}
+
+// A repository managed in the projects directory
+type managedRepository struct {
+	path       string
+	visibility string
+	input      *githubfoundations.RepositoryInput
+}
+
+// managedRepositories indexes the repositories of the projects directory by organization and name
+func managedRepositories(orgSet status.OrgSet) map[string]managedRepository {
+	repos := make(map[string]managedRepository)
+	for org, projects := range orgSet.OrgProjectSets {
+		for project, repoSet := range projects.RepositorySets {
+			for _, r := range repoSet.PrivateRepositories {
+				repos[org+"/"+r.Name] = managedRepository{projects.Paths[project], "private_repositories", r}
+			}
+			for _, r := range repoSet.PublicRepositories {
+				repos[org+"/"+r.Name] = managedRepository{projects.Paths[project], "public_repositories", r}
+			}
+		}
+	}
+	return repos
+}
+
+// A violation of a report, recorded once even when several profiles report it
+type violation struct {
+	organization string
+	entityType   string
+	entityId     string
+	key          string
+}
+
+func sortedViolations(reports []types.CheckReport) []violation {
+	seen := make(map[violation]bool)
+	var violations []violation
+	for _, report := range reports {
+		for _, checkError := range report.Errors {
+			for key := range checkError.Violations {
+				v := violation{report.Organization, report.EntityType, report.EntityId, key}
+				if !seen[v] {
+					seen[v] = true
+					violations = append(violations, v)
+				}
+			}
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.organization != b.organization {
+			return a.organization < b.organization
+		}
+		if a.entityType != b.entityType {
+			return a.entityType < b.entityType
+		}
+		if a.entityId != b.entityId {
+			return a.entityId < b.entityId
+		}
+		return a.key < b.key
+	})
+	return violations
+}
+
+// NewPlan maps the violations of the reports to the inputs of the repositories managed in the projects directory
+// and rewrites their HCL files. Violations of rules without an input, of unmanaged repositories and of inputs
+// that already comply are reported as unfixable.
+func NewPlan(reports []types.CheckReport, orgSet status.OrgSet, registry *types.CheckRegistry) *Plan {
+	plan := &Plan{Changes: []Change{}, Unfixable: []Unfixable{}, files: make(map[string]*hclFile)}
+	managed := managedRepositories(orgSet)
+	changes := make(map[string]int)
+
+	for _, v := range sortedViolations(reports) {
+		unfixable := func(reason string) {
+			plan.Unfixable = append(plan.Unfixable, Unfixable{v.organization, v.entityType, v.entityId, v.key, reason})
+		}
+		if v.entityType != github.RepositoryEntityType {
+			unfixable("only the inputs of repositories can be remediated")
+			continue
+		}
+		ruleId := v.key
+		if rule, ok := registry.RuleForKey(v.key); ok {
+			ruleId = rule.Id
+		}
+		fix, ok := repositoryFixes[ruleId]
+		if !ok {
+			unfixable(fmt.Sprintf("the foundations module has no input fixing %s", ruleId))
+			continue
+		}
+		repo, ok := managed[v.organization+"/"+v.entityId]
+		if !ok {
+			unfixable("the repository is not managed in the projects directory")
+			continue
+		}
+
+		id := fmt.Sprintf("%s/%s/%s", v.organization, v.entityId, fix.input)
+		if i, ok := changes[id]; ok {
+			plan.Changes[i].Violations = append(plan.Changes[i].Violations, v.key)
+			continue
+		}
+		if fix.unfixable != nil {
+			if reason := fix.unfixable(repo.input); reason != "" {
+				unfixable(fmt.Sprintf("%s in %s", reason, repo.path))
+				continue
+			}
+		}
+		value, ok := fix.value(repo.input)
+		if !ok {
+			unfixable(fmt.Sprintf("%s already complies in %s. The inputs may not have been applied", fix.input, repo.path))
+			continue
+		}
+		file, err := plan.file(repo.path)
+		if err == nil {
+			err = file.set(repo.visibility, repo.input.Name, fix.input, value)
+		}
+		if err != nil {
+			unfixable(err.Error())
+			continue
+		}
+
+		changes[id] = len(plan.Changes)
+		plan.Changes = append(plan.Changes, Change{
+			Organization: v.organization,
+			Repository:   v.entityId,
+			Path:         repo.path,
+			Input:        fix.input,
+			Value:        string(hclwrite.TokensForValue(value).Bytes()),
+			Violations:   []string{v.key},
+		})
+	}
+	return plan
+}
+
+func (p *Plan) file(path string) (*hclFile, error) {
+	if file, ok := p.files[path]; ok {
+		return file, nil
+	}
+	file, err := parseHCLFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p.files[path] = file
+	return file, nil
+}
+
+// changedFiles returns the paths of the files with edits in a stable order
+func (p *Plan) changedFiles() []string {
+	var paths []string
+	for path, file := range p.files {
+		if len(file.edits) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// WritePatch writes the rewrite of the HCL files as a unified diff that can be applied with git apply or patch -p1
+func (p *Plan) WritePatch(w io.Writer) error {
+	for _, path := range p.changedFiles() {
+		file := p.files[path]
+		name := strings.TrimPrefix(filepath.ToSlash(path), "/")
+		patch, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(file.src)),
+			B:        difflib.SplitLines(string(file.rewrite())),
+			FromFile: "a/" + name,
+			ToFile:   "b/" + name,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSummary lists the changes of the plan and the violations it cannot fix
+func (p *Plan) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(p.Changes) > 0 {
+		fmt.Fprintln(tw, "ORGANIZATION\tREPOSITORY\tINPUT\tVALUE\tVIOLATIONS\tFILE")
+		for _, c := range p.Changes {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.Organization, c.Repository, c.Input, c.Value, strings.Join(c.Violations, ", "), c.Path)
+		}
+		fmt.Fprintln(tw)
+	}
+	if len(p.Unfixable) > 0 {
+		fmt.Fprintln(tw, "ORGANIZATION\tENTITY TYPE\tENTITY\tVIOLATION\tNOT FIXABLE BECAUSE")
+		for _, u := range p.Unfixable {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", u.Organization, u.EntityType, u.EntityId, u.Violation, u.Reason)
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d input changes in %d files, %d violations cannot be fixed through the inputs\n", len(p.Changes), len(p.changedFiles()), len(p.Unfixable))
+	return err
+}
+
+// An edit replaces the bytes between start and end of a file
+type edit struct {
+	start int
+	end   int
+	text  string
+	// Applied after the other edits starting at the same offset
+	last bool
+}
+
+// An hclFile is a terragrunt.hcl file of the projects directory and the edits of its inputs
+type hclFile struct {
+	path   string
+	src    []byte
+	inputs *hclsyntax.ObjectConsExpr
+	edits  []edit
+}
+
+func parseHCLFile(path string) (*hclFile, error) {
+	src, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %s: %w", path, err)
+	}
+	file, diags := hclsyntax.ParseConfig(src, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("unable to parse %s: %s", path, diags.Error())
+	}
+	attr, ok := file.Body.(*hclsyntax.Body).Attributes["inputs"]
+	if !ok {
+		return nil, fmt.Errorf("%s has no inputs", path)
+	}
+	inputs, ok := attr.Expr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil, fmt.Errorf("the inputs of %s are not an object", path)
+	}
+	return &hclFile{path: path, src: src, inputs: inputs}, nil
+}
+
+// item returns the item of the object with the given key. Keys are either identifiers or quoted strings.
+func item(object *hclsyntax.ObjectConsExpr, key string) (hclsyntax.ObjectConsItem, bool) {
+	for _, i := range object.Items {
+		k, diags := i.KeyExpr.Value(nil)
+		if !diags.HasErrors() && k.Type() == cty.String && k.IsKnown() && !k.IsNull() && k.AsString() == key {
+			return i, true
+		}
+	}
+	return hclsyntax.ObjectConsItem{}, false
+}
+
+func objectItem(object *hclsyntax.ObjectConsExpr, key string, path string) (*hclsyntax.ObjectConsExpr, error) {
+	i, ok := item(object, key)
+	if !ok {
+		return nil, fmt.Errorf("%s not found in %s", key, path)
+	}
+	value, ok := i.ValueExpr.(*hclsyntax.ObjectConsExpr)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an object in %s", key, path)
+	}
+	return value, nil
+}
+
+// set records the edit setting the input of the repository to the value. Missing inputs are added at the end of the repository.
+func (f *hclFile) set(visibility string, name string, input string, value cty.Value) error {
+	repos, err := objectItem(f.inputs, visibility, f.path)
+	if err != nil {
+		return err
+	}
+	repo, err := objectItem(repos, name, f.path)
+	if err != nil {
+		return err
+	}
+	text := string(hclwrite.TokensForValue(value).Bytes())
+
+	if i, ok := item(repo, input); ok {
+		r := i.ValueExpr.Range()
+		f.edits = append(f.edits, edit{start: r.Start.Byte, end: r.End.Byte, text: text})
+		return nil
+	}
+
+	// Inputs are added before the closing brace of the repository, indented like its first input
+	brace := repo.SrcRange.End.Byte - 1
+	lineStart := bytes.LastIndexByte(f.src[:brace], '\n') + 1
+	line := f.src[lineStart:brace]
+	lineIndent := string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+	itemIndent := lineIndent + "  "
+	if len(repo.Items) > 0 {
+		first := repo.Items[0].KeyExpr.Range().Start.Byte
+		firstLine := f.src[bytes.LastIndexByte(f.src[:first], '\n')+1 : first]
+		if len(bytes.TrimSpace(firstLine)) == 0 {
+			itemIndent = string(firstLine)
+		}
+	}
+	if len(bytes.TrimSpace(line)) == 0 {
+		f.edits = append(f.edits, edit{start: lineStart, end: lineStart, text: fmt.Sprintf("%s%s = %s\n", itemIndent, input, text)})
+		return nil
+	}
+
+	// The brace closes an object written on a single line. The inputs are added on their own lines after its
+	// last item, and the brace is moved to its own line once.
+	end := lineStart + len(bytes.TrimRight(line, " \t"))
+	split := edit{start: end, end: brace, text: "\n" + lineIndent, last: true}
+	if !slices.Contains(f.edits, split) {
+		f.edits = append(f.edits, split)
+	}
+	f.edits = append(f.edits, edit{start: end, end: end, text: fmt.Sprintf("\n%s%s = %s", itemIndent, input, text)})
+	return nil
+}
+
+// rewrite applies the edits to the file. Files that were formatted are formatted again to align the inputs.
+func (f *hclFile) rewrite() []byte {
+	// Edits starting at the same offset are applied in the order they were added
+	edits := slices.Clone(f.edits)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return !edits[i].last && edits[j].last
+	})
+
+	var out bytes.Buffer
+	pos := 0
+	for _, e := range edits {
+		out.Write(f.src[pos:e.start])
+		out.WriteString(e.text)
+		pos = e.end
+	}
+	out.Write(f.src[pos:])
+
+	if bytes.Equal(hclwrite.Format(f.src), f.src) {
+		return hclwrite.Format(out.Bytes())
+	}
+	return out.Bytes()
+}
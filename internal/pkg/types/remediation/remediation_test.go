@@ -0,0 +1,190 @@
+package remediation
+
+import (
+	"bytes"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/github"
+	githubfoundations "gh_foundations/internal/pkg/types/github_foundations"
+	"gh_foundations/internal/pkg/types/status"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const testRepositories = `include "root" {
+  path = find_in_parent_folders()
+}
+
+inputs = {
+  private_repositories = {
+    api = {
+      description          = "API"
+      default_branch       = "main"
+      delete_head_on_merge = false
+      protected_branches   = []
+    }
+  }
+  public_repositories = {
+    "docs-site" = {
+      description    = "Docs"
+      default_branch = "main"
+    }
+  }
+}
+`
+
+const testPath = "projects/platform/acme/repositories/terragrunt.hcl"
+
+func newTestOrgSet() status.OrgSet {
+	return status.OrgSet{OrgProjectSets: map[string]status.OrgProjectSet{
+		"acme": {
+			RepositorySets: map[string]githubfoundations.RepositorySetInput{
+				"platform": {
+					PrivateRepositories: []*githubfoundations.RepositoryInput{{Name: "api", DefaultBranch: "main"}},
+					PublicRepositories:  []*githubfoundations.RepositoryInput{{Name: "docs-site", DefaultBranch: "main", AdvanceSecurity: true}},
+				},
+			},
+			Paths: map[string]string{"platform": testPath},
+		},
+	}}
+}
+
+func newTestReport(entityType string, entityId string, violations map[string]string) types.CheckReport {
+	return types.CheckReport{
+		EntityType:   entityType,
+		EntityId:     entityId,
+		Organization: "acme",
+		Errors:       []types.CheckError{{Check: types.GoCGuardrails, Violations: violations}},
+	}
+}
+
+func newTestRegistry(t *testing.T) *types.CheckRegistry {
+	registry := types.NewCheckRegistry()
+	for _, id := range []string{"delete_branch_on_merge", "dependabot_security_updates", "secret_scanning", "secret_scanning_push_protection", "rulesets", "team_privacy"} {
+		assert.NoError(t, registry.Register(types.Rule{Id: id, EntityType: github.RepositoryEntityType, Evaluate: func(any) error { return nil }}))
+	}
+	return registry
+}
+
+func TestNewPlan(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, testPath, []byte(testRepositories), 0644)
+	reports := []types.CheckReport{
+		newTestReport(github.RepositoryEntityType, "api", map[string]string{
+			"delete_branch_on_merge":          "delete_branch_on_merge is not enabled. Expected it to be enabled",
+			"secret_scanning":                 "secret_scanning is not enabled. Expected it to be enabled",
+			"secret_scanning_push_protection": "secret_scanning_push_protection is not enabled. Expected it to be enabled",
+			"rulesets.pull_request":           "pull_request rule is missing",
+		}),
+		newTestReport(github.RepositoryEntityType, "docs-site", map[string]string{
+			"secret_scanning":             "secret_scanning is not enabled. Expected it to be enabled",
+			"dependabot_security_updates": "dependabot_security_updates is not enabled. Expected it to be enabled",
+		}),
+	}
+
+	plan := NewPlan(reports, newTestOrgSet(), newTestRegistry(t))
+
+	assert.Equal(t, []Change{
+		{Organization: "acme", Repository: "api", Path: testPath, Input: "delete_head_on_merge", Value: "true", Violations: []string{"delete_branch_on_merge"}},
+		{Organization: "acme", Repository: "api", Path: testPath, Input: "protected_branches", Value: `["main"]`, Violations: []string{"rulesets.pull_request"}},
+		{Organization: "acme", Repository: "api", Path: testPath, Input: "advance_security", Value: "true", Violations: []string{"secret_scanning", "secret_scanning_push_protection"}},
+		{Organization: "acme", Repository: "docs-site", Path: testPath, Input: "dependabot_security_updates", Value: "true", Violations: []string{"dependabot_security_updates"}},
+	}, plan.Changes)
+	assert.Equal(t, []Unfixable{
+		{Organization: "acme", EntityType: github.RepositoryEntityType, EntityId: "docs-site", Violation: "secret_scanning", Reason: "advance_security already complies in " + testPath + ". The inputs may not have been applied"},
+	}, plan.Unfixable)
+
+	assert.Equal(t, `include "root" {
+  path = find_in_parent_folders()
+}
+
+inputs = {
+  private_repositories = {
+    api = {
+      description          = "API"
+      default_branch       = "main"
+      delete_head_on_merge = true
+      protected_branches   = ["main"]
+      advance_security     = true
+    }
+  }
+  public_repositories = {
+    "docs-site" = {
+      description                 = "Docs"
+      default_branch              = "main"
+      dependabot_security_updates = true
+    }
+  }
+}
+`, string(plan.files[testPath].rewrite()))
+}
+
+func TestNewPlan_Unfixable(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, testPath, []byte(testRepositories), 0644)
+	reports := []types.CheckReport{
+		newTestReport("github_organization", "acme", map[string]string{"members_can_create_public_repositories": "enabled"}),
+		newTestReport(github.RepositoryEntityType, "legacy", map[string]string{"delete_branch_on_merge": "not enabled"}),
+		newTestReport(github.RepositoryEntityType, "api", map[string]string{"team_privacy": "secret"}),
+	}
+
+	plan := NewPlan(reports, newTestOrgSet(), newTestRegistry(t))
+
+	assert.Empty(t, plan.Changes)
+	assert.Equal(t, []string{
+		"only the inputs of repositories can be remediated",
+		"the foundations module has no input fixing team_privacy",
+		"the repository is not managed in the projects directory",
+	}, []string{plan.Unfixable[0].Reason, plan.Unfixable[1].Reason, plan.Unfixable[2].Reason})
+
+	var patch bytes.Buffer
+	assert.NoError(t, plan.WritePatch(&patch))
+	assert.Empty(t, patch.String())
+}
+
+func TestNewPlan_NoDefaultBranch(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, testPath, []byte(testRepositories), 0644)
+	orgSet := newTestOrgSet()
+	orgSet.OrgProjectSets["acme"].RepositorySets["platform"].PrivateRepositories[0].DefaultBranch = ""
+	reports := []types.CheckReport{newTestReport(github.RepositoryEntityType, "api", map[string]string{"rulesets.pull_request": "pull_request rule is missing"})}
+
+	plan := NewPlan(reports, orgSet, newTestRegistry(t))
+
+	assert.Empty(t, plan.Changes)
+	assert.Equal(t, []Unfixable{
+		{Organization: "acme", EntityType: github.RepositoryEntityType, EntityId: "api", Violation: "rulesets.pull_request", Reason: "the repository has no default_branch input to protect in " + testPath},
+	}, plan.Unfixable)
+}
+
+func TestHclFile_UnformattedFile(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, "/repositories.hcl", []byte("inputs = {\n  private_repositories = {\n    api = { description = \"API\"}\n  }\n  # unaligned\n  x   = 1\n}\n"), 0644)
+
+	file, err := parseHCLFile("/repositories.hcl")
+	assert.NoError(t, err)
+	assert.NoError(t, file.set("private_repositories", "api", "delete_head_on_merge", cty.True))
+	assert.NoError(t, file.set("private_repositories", "api", "advance_security", cty.True))
+
+	assert.Equal(t, "inputs = {\n  private_repositories = {\n    api = { description = \"API\"\n      delete_head_on_merge = true\n      advance_security = true\n    }\n  }\n  # unaligned\n  x   = 1\n}\n", string(file.rewrite()))
+	assert.ErrorContains(t, file.set("public_repositories", "api", "delete_head_on_merge", cty.True), "public_repositories not found in /repositories.hcl")
+}
+
+func TestPlan_WritePatch(t *testing.T) {
+	fs = afero.NewMemMapFs()
+	afero.WriteFile(fs, testPath, []byte(testRepositories), 0644)
+	reports := []types.CheckReport{newTestReport(github.RepositoryEntityType, "api", map[string]string{"delete_branch_on_merge": "not enabled"})}
+
+	plan := NewPlan(reports, newTestOrgSet(), newTestRegistry(t))
+
+	var patch bytes.Buffer
+	assert.NoError(t, plan.WritePatch(&patch))
+	assert.Contains(t, patch.String(), "--- a/"+testPath+"\n+++ b/"+testPath+"\n")
+	assert.Contains(t, patch.String(), "-      delete_head_on_merge = false\n+      delete_head_on_merge = true\n")
+
+	var summary bytes.Buffer
+	assert.NoError(t, plan.WriteSummary(&summary))
+	assert.Contains(t, summary.String(), "1 input changes in 1 files, 0 violations cannot be fixed through the inputs")
+}
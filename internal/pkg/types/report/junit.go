@@ -0,0 +1,115 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitWriter writes each entity as a test suite and each evaluated rule as a test case.
+type JUnitWriter struct {
+	Registry *types.CheckRegistry
+}
+
+func (j *JUnitWriter) Write(w io.Writer, reports []types.CheckReport) error {
+	suites := junitTestSuites{Name: toolName, Suites: []junitTestSuite{}}
+
+	for _, report := range reports {
+		suite := junitTestSuite{
+			Name:      fmt.Sprintf("%s/%s", report.EntityType, report.EntityId),
+			Timestamp: report.Timestamp,
+			Cases:     []junitTestCase{},
+		}
+
+		for _, outcome := range ruleOutcomes(report, j.Registry) {
+			testCase := junitTestCase{
+				Name:      outcome.Rule.Id,
+				ClassName: string(outcome.Check),
+			}
+			switch outcome.Result {
+			case types.Failed:
+				text := outcome.Rule.Description
+				if len(outcome.Controls) > 0 {
+					text = fmt.Sprintf("%s\nControls: %s", text, controlIds(outcome.Controls))
+				}
+				testCase.Failure = &junitMessage{Message: outcome.Message, Type: outcome.Rule.Severity.String(), Text: text}
+				suite.Failures++
+			case types.Errored:
+				testCase.Error = &junitMessage{Message: outcome.Message, Text: outcome.Rule.Description}
+				suite.Errors++
+			case types.NotApplicable:
+				testCase.Skipped = &junitMessage{Message: outcome.Result.String()}
+				suite.Skipped++
+			case types.Suppressed:
+				testCase.Skipped = &junitMessage{Message: fmt.Sprintf("%s: %s", outcome.Result, outcome.Message)}
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, testCase)
+		}
+
+		// Profiles with no rule for the entity type have no outcome and are reported as a single skipped case
+		for _, t := range sortedCheckTypes(report) {
+			if result := report.Results[t]; result == types.NotApplicable {
+				suite.Cases = append(suite.Cases, junitTestCase{
+					Name:      string(t),
+					ClassName: string(t),
+					Skipped:   &junitMessage{Message: result.String()},
+				})
+				suite.Skipped++
+			}
+		}
+
+		suite.Tests = len(suite.Cases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Errors += suite.Errors
+		suites.Skipped += suite.Skipped
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"strings"
+)
+
+// MarkdownWriter writes a summary table of every entity followed by a table of the violations.
+// Reports spanning several organizations start with a summary of each organization.
+type MarkdownWriter struct {
+	Registry *types.CheckRegistry
+}
+
+func (m *MarkdownWriter) Write(w io.Writer, reports []types.CheckReport) error {
+	var b strings.Builder
+
+	b.WriteString("## Check results\n\n")
+	if summaries := SummarizeOrganizations(reports, m.Registry); len(summaries) > 1 {
+		b.WriteString("| Organization | Entities | Passed | Failed | Errored | Violations | Suppressed |\n")
+		b.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+		for _, s := range summaries {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %d | %d |\n", escapeMarkdown(s.Organization), s.Entities, s.Passed, s.Failed, s.Errored, s.Total(), s.Suppressed)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("| Entity | Type | Profile | Result |\n")
+	b.WriteString("| --- | --- | --- | --- |\n")
+	for _, report := range reports {
+		for _, t := range sortedCheckTypes(report) {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", escapeMarkdown(report.EntityId), report.EntityType, t, report.Results[t])
+		}
+	}
+
+	var outcomes []ruleOutcome
+	var entities []string
+	withControls := false
+	for _, report := range reports {
+		for _, outcome := range ruleOutcomes(report, m.Registry) {
+			if outcome.Result == types.Passed {
+				continue
+			}
+			outcomes = append(outcomes, outcome)
+			entities = append(entities, report.EntityId)
+			withControls = withControls || len(outcome.Controls) > 0
+		}
+	}
+
+	if len(outcomes) > 0 {
+		b.WriteString("\n### Violations\n\n")
+		// Reports of benchmark profiles link every violation to the controls it fails
+		if withControls {
+			b.WriteString("| Entity | Rule | Severity | Result | Controls | Message |\n")
+			b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+		} else {
+			b.WriteString("| Entity | Rule | Severity | Result | Message |\n")
+			b.WriteString("| --- | --- | --- | --- | --- |\n")
+		}
+		for i, outcome := range outcomes {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s |", escapeMarkdown(entities[i]), outcome.Rule.Id, outcome.Rule.Severity, outcome.Result)
+			if withControls {
+				fmt.Fprintf(&b, " %s |", markdownControls(outcome.Controls))
+			}
+			fmt.Fprintf(&b, " %s |\n", escapeMarkdown(outcome.Message))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownControls(controls []types.Control) string {
+	links := make([]string, 0, len(controls))
+	for _, c := range controls {
+		if c.Url == "" {
+			links = append(links, escapeMarkdown(c.Id))
+		} else {
+			links = append(links, fmt.Sprintf("[%s](%s)", escapeMarkdown(c.Id), c.Url))
+		}
+	}
+	return strings.Join(links, ", ")
+}
+
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A sample of a metric, with its labels as name and value pairs
+type metricSample struct {
+	labels []string
+	value  int
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeMetric(w io.Writer, name string, help string, samples []metricSample) error {
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name); err != nil {
+		return err
+	}
+	for _, s := range samples {
+		labels := make([]string, 0, len(s.labels)/2)
+		for i := 0; i+1 < len(s.labels); i += 2 {
+			labels = append(labels, fmt.Sprintf(`%s="%s"`, s.labels[i], labelEscaper.Replace(s.labels[i+1])))
+		}
+		if _, err := fmt.Fprintf(w, "%s{%s} %d\n", name, strings.Join(labels, ","), s.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricResult names a result in the label values
+func metricResult(result types.CheckResult) string {
+	return strings.ReplaceAll(strings.ToLower(result.String()), " ", "_")
+}
+
+// The precedence of results when the outcomes of the sub keys of a rule are collapsed into the outcome of the rule
+var resultPrecedence = map[types.CheckResult]int{
+	types.Passed:     0,
+	types.Suppressed: 1,
+	types.Failed:     2,
+	types.Errored:    3,
+}
+
+// ruleResults counts the entities of every organization by the outcome of each rule of each profile.
+// A rule fails for an entity when any of its sub keys fails.
+func ruleResults(reports []types.CheckReport, registry *types.CheckRegistry) []metricSample {
+	type ruleKey struct {
+		organization string
+		check        types.CheckType
+		rule         string
+		severity     types.Severity
+		result       types.CheckResult
+	}
+	counts := make(map[ruleKey]int)
+	for _, report := range reports {
+		// The outcome of each rule for the entity, collapsing the outcomes of its sub keys
+		results := make(map[ruleKey]types.CheckResult)
+		for _, outcome := range ruleOutcomes(report, registry) {
+			id := outcome.Rule.Id
+			if rule, ok := registry.RuleForKey(id); ok {
+				id = rule.Id
+			}
+			key := ruleKey{report.Organization, outcome.Check, id, outcome.Rule.Severity, types.Passed}
+			if current, ok := results[key]; !ok || resultPrecedence[outcome.Result] > resultPrecedence[current] {
+				results[key] = outcome.Result
+			}
+		}
+		for key, result := range results {
+			key.result = result
+			counts[key]++
+		}
+	}
+
+	keys := make([]ruleKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.organization != b.organization {
+			return a.organization < b.organization
+		}
+		if a.check != b.check {
+			return a.check < b.check
+		}
+		if a.rule != b.rule {
+			return a.rule < b.rule
+		}
+		return a.result < b.result
+	})
+
+	samples := make([]metricSample, 0, len(keys))
+	for _, k := range keys {
+		samples = append(samples, metricSample{
+			labels: []string{
+				"organization", k.organization,
+				"profile", string(k.check),
+				"rule", k.rule,
+				"severity", strings.ToLower(k.severity.String()),
+				"result", metricResult(k.result),
+			},
+			value: counts[k],
+		})
+	}
+	return samples
+}
+
+// WriteMetrics writes the outcome of every rule, the result of the entities and the violations of each
+// organization in the Prometheus text exposition format
+func WriteMetrics(w io.Writer, reports []types.CheckReport, registry *types.CheckRegistry) error {
+	if err := writeMetric(w, "gh_foundations_rule_entities", "Number of entities by outcome of a rule", ruleResults(reports, registry)); err != nil {
+		return err
+	}
+
+	var entities, violations []metricSample
+	for _, s := range SummarizeOrganizations(reports, registry) {
+		for _, count := range []struct {
+			result types.CheckResult
+			value  int
+		}{{types.Passed, s.Passed}, {types.Failed, s.Failed}, {types.Errored, s.Errored}} {
+			entities = append(entities, metricSample{labels: []string{"organization", s.Organization, "result", metricResult(count.result)}, value: count.value})
+		}
+		for _, severity := range []types.Severity{types.Critical, types.High, types.Medium, types.Low} {
+			violations = append(violations, metricSample{labels: []string{"organization", s.Organization, "severity", strings.ToLower(severity.String())}, value: s.Violations[severity]})
+		}
+		violations = append(violations, metricSample{labels: []string{"organization", s.Organization, "severity", "suppressed"}, value: s.Suppressed})
+	}
+	if err := writeMetric(w, "gh_foundations_entities", "Number of checked entities by overall result", entities); err != nil {
+		return err
+	}
+	return writeMetric(w, "gh_foundations_violations", "Number of violations by severity of the rule. Suppressed violations are counted apart", violations)
+}
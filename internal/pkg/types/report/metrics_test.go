@@ -0,0 +1,57 @@
+package report
+
+import (
+	"bytes"
+	"gh_foundations/internal/pkg/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	reports := newTestReports()
+	for i := range reports {
+		reports[i].Organization = "acme"
+	}
+	// The sub keys of a rule are counted as the rule
+	reports[1].Results[types.GoCGuardrails] = types.Failed
+	reports[1].Errors = []types.CheckError{{
+		Check: types.GoCGuardrails,
+		Violations: map[string]string{
+			"secret_scanning.push_protection": "push protection is not enabled",
+			"secret_scanning.validity_checks": "validity checks are not enabled",
+		},
+	}}
+	buffer := &bytes.Buffer{}
+
+	err := WriteMetrics(buffer, reports, newTestRegistry())
+
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP gh_foundations_rule_entities Number of entities by outcome of a rule
+# TYPE gh_foundations_rule_entities gauge
+gh_foundations_rule_entities{organization="acme",profile="GoCGuardrails",rule="delete_branch_on_merge",severity="low",result="passed"} 2
+gh_foundations_rule_entities{organization="acme",profile="GoCGuardrails",rule="secret_scanning",severity="high",result="failed"} 2
+gh_foundations_rule_entities{organization="acme",profile="GoCGuardrails",rule="unregistered",severity="medium",result="failed"} 1
+# HELP gh_foundations_entities Number of checked entities by overall result
+# TYPE gh_foundations_entities gauge
+gh_foundations_entities{organization="acme",result="passed"} 0
+gh_foundations_entities{organization="acme",result="failed"} 2
+gh_foundations_entities{organization="acme",result="errored"} 0
+# HELP gh_foundations_violations Number of violations by severity of the rule. Suppressed violations are counted apart
+# TYPE gh_foundations_violations gauge
+gh_foundations_violations{organization="acme",severity="critical"} 0
+gh_foundations_violations{organization="acme",severity="high"} 3
+gh_foundations_violations{organization="acme",severity="medium"} 1
+gh_foundations_violations{organization="acme",severity="low"} 0
+gh_foundations_violations{organization="acme",severity="suppressed"} 0
+`, buffer.String())
+}
+
+func TestWriteMetric_EscapesLabels(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	err := writeMetric(buffer, "test", "help", []metricSample{{labels: []string{"organization", "a\"b\\c\nd"}, value: 1}})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), `test{organization="a\"b\\c\nd"} 1`)
+}
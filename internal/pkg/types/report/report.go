@@ -0,0 +1,177 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A ReportWriter serializes check reports in a specific format.
+type ReportWriter interface {
+	Write(w io.Writer, reports []types.CheckReport) error
+}
+
+// Formats supported by NewReportWriter mapped to the file extension of their default output file
+var Formats = map[string]string{
+	"json":     "json",
+	"sarif":    "sarif",
+	"junit":    "xml",
+	"markdown": "md",
+	"table":    "txt",
+}
+
+func NewReportWriter(format string, registry *types.CheckRegistry) (ReportWriter, error) {
+	switch format {
+	case "json":
+		return &JsonWriter{}, nil
+	case "sarif":
+		return &SarifWriter{Registry: registry}, nil
+	case "junit":
+		return &JUnitWriter{Registry: registry}, nil
+	case "markdown":
+		return &MarkdownWriter{Registry: registry}, nil
+	case "table":
+		return &TableWriter{Registry: registry}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+type JsonWriter struct{}
+
+func (j *JsonWriter) Write(w io.Writer, reports []types.CheckReport) error {
+	return json.NewEncoder(w).Encode(reports)
+}
+
+// The outcome of a single rule of a profile evaluated against an entity
+type ruleOutcome struct {
+	Check   types.CheckType
+	Rule    types.Rule
+	Result  types.CheckResult
+	Message string
+	// Benchmark controls the rule provides evidence for in the profile
+	Controls []types.Control
+}
+
+// The check types of a report in a stable order
+func sortedCheckTypes(report types.CheckReport) []types.CheckType {
+	checkTypes := make([]types.CheckType, 0, len(report.Results))
+	for t := range report.Results {
+		checkTypes = append(checkTypes, t)
+	}
+	sort.Slice(checkTypes, func(i, j int) bool { return checkTypes[i] < checkTypes[j] })
+	return checkTypes
+}
+
+// lookupRule returns the rule that recorded a violation key. Violations reported under a sub key of a rule
+// keep the key as their id and inherit the rest from the rule.
+func lookupRule(registry *types.CheckRegistry, key string, entityType string) types.Rule {
+	rule, ok := registry.RuleForKey(key)
+	if !ok {
+		return types.Rule{Id: key, Description: key, Severity: types.Medium, EntityType: entityType}
+	}
+	rule.Id = key
+	return rule
+}
+
+// controlIds joins the ids of the controls
+func controlIds(controls []types.Control) string {
+	ids := make([]string, 0, len(controls))
+	for _, c := range controls {
+		ids = append(ids, c.Id)
+	}
+	return strings.Join(ids, ", ")
+}
+
+// hasSubKeys reports whether any key is a sub key of the rule
+func hasSubKeys(keys map[string]string, ruleId string) bool {
+	for key := range keys {
+		if strings.HasPrefix(key, ruleId+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleOutcomes expands a report into the outcome of every rule that was evaluated.
+// Reports only record violations, so the passing rules are recovered from the profiles in the registry.
+func ruleOutcomes(report types.CheckReport, registry *types.CheckRegistry) []ruleOutcome {
+	var outcomes []ruleOutcome
+	for _, t := range sortedCheckTypes(report) {
+		violations := make(map[string]string)
+		suppressed := make(map[string]string)
+		unevaluated := make(map[string]string)
+		for _, checkError := range report.Errors {
+			if checkError.Check == t {
+				for key, message := range checkError.Violations {
+					violations[key] = message
+				}
+				for key, justification := range checkError.Suppressed {
+					suppressed[key] = justification
+				}
+				for key, reason := range checkError.Unevaluated {
+					unevaluated[key] = reason
+				}
+			}
+		}
+
+		evaluated := make(map[string]bool)
+		for _, rule := range registry.RulesFor(t, report.EntityType) {
+			evaluated[rule.Id] = true
+			// The outcomes of the sub keys are reported in place of the rule
+			if hasSubKeys(violations, rule.Id) || hasSubKeys(suppressed, rule.Id) {
+				continue
+			}
+			outcome := ruleOutcome{Check: t, Rule: rule, Result: types.Passed, Controls: registry.Controls(t, rule.Id)}
+			if reason, ok := unevaluated[rule.Id]; ok {
+				outcome.Result = types.Errored
+				outcome.Message = reason
+			} else if message, ok := violations[rule.Id]; ok {
+				outcome.Result = types.Failed
+				outcome.Message = message
+			} else if justification, ok := suppressed[rule.Id]; ok {
+				outcome.Result = types.Suppressed
+				outcome.Message = justification
+			}
+			outcomes = append(outcomes, outcome)
+		}
+
+		// Violations that do not map to a registered rule of the profile are still reported
+		keys := make([]string, 0, len(violations))
+		for key := range violations {
+			if !evaluated[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			outcomes = append(outcomes, ruleOutcome{
+				Check:    t,
+				Rule:     lookupRule(registry, key, report.EntityType),
+				Result:   types.Failed,
+				Message:  violations[key],
+				Controls: registry.Controls(t, key),
+			})
+		}
+		keys = keys[:0]
+		for key := range suppressed {
+			if !evaluated[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			outcomes = append(outcomes, ruleOutcome{
+				Check:    t,
+				Rule:     lookupRule(registry, key, report.EntityType),
+				Result:   types.Suppressed,
+				Message:  suppressed[key],
+				Controls: registry.Controls(t, key),
+			})
+		}
+	}
+	return outcomes
+}
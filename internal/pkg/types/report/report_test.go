@@ -0,0 +1,154 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"gh_foundations/internal/pkg/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReportWriter_UnsupportedFormat(t *testing.T) {
+	_, err := NewReportWriter("yaml", newTestRegistry())
+
+	assert.Error(t, err)
+}
+
+func TestJUnitWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := &JUnitWriter{Registry: newTestRegistry()}
+
+	err := writer.Write(buffer, newTestReports())
+	assert.NoError(t, err)
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &suites))
+	assert.Len(t, suites.Suites, 2)
+	assert.Equal(t, 5, suites.Tests)
+	assert.Equal(t, 2, suites.Failures)
+
+	docs := suites.Suites[0]
+	assert.Equal(t, "github_repository/docs", docs.Name)
+	assert.Equal(t, "secret_scanning", docs.Cases[0].Name)
+	assert.NotNil(t, docs.Cases[0].Failure)
+	assert.Equal(t, "delete_branch_on_merge", docs.Cases[1].Name)
+	assert.Nil(t, docs.Cases[1].Failure)
+	assert.Equal(t, "unregistered", docs.Cases[2].Name)
+	assert.NotNil(t, docs.Cases[2].Failure)
+
+	api := suites.Suites[1]
+	assert.Equal(t, 2, api.Tests)
+	assert.Equal(t, 0, api.Failures)
+}
+
+func TestJUnitWriter_Errored(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := &JUnitWriter{Registry: newTestRegistry()}
+	reports := []types.CheckReport{{
+		EntityType: "github_repository",
+		EntityId:   "docs",
+		Results:    map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Errored},
+		Errors: []types.CheckError{{
+			Check:       types.GoCGuardrails,
+			Violations:  map[string]string{},
+			Unevaluated: map[string]string{"secret_scanning": "unable to read the security settings: 403 Forbidden"},
+		}},
+	}}
+
+	assert.NoError(t, writer.Write(buffer, reports))
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(buffer.Bytes(), &suites))
+	assert.Equal(t, 1, suites.Errors)
+	assert.Equal(t, "secret_scanning", suites.Suites[0].Cases[0].Name)
+	assert.Equal(t, "unable to read the security settings: 403 Forbidden", suites.Suites[0].Cases[0].Error.Message)
+	assert.Nil(t, suites.Suites[0].Cases[1].Error)
+}
+
+func TestMarkdownWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	writer := &MarkdownWriter{Registry: newTestRegistry()}
+
+	err := writer.Write(buffer, newTestReports())
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "| docs | github_repository | GoCGuardrails | Failed |")
+	assert.Contains(t, buffer.String(), "| api | github_repository | GoCGuardrails | Passed |")
+	assert.Contains(t, buffer.String(), "| docs | secret_scanning | High | Failed | secret_scanning is not enabled. Expected it to be enabled |")
+	assert.NotContains(t, buffer.String(), "| api | delete_branch_on_merge")
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(newTestReports(), newTestRegistry())
+
+	assert.Equal(t, 2, summary.Entities)
+	assert.Equal(t, 1, summary.Passed)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 0, summary.Errored)
+	assert.Equal(t, 1, summary.Violations[types.High])
+	assert.Equal(t, 1, summary.Violations[types.Medium])
+	assert.Equal(t, 2, summary.ViolationsAtOrAbove(types.Low))
+	assert.Equal(t, 1, summary.ViolationsAtOrAbove(types.High))
+	assert.Equal(t, 0, summary.ViolationsAtOrAbove(types.Critical))
+	assert.Equal(t, "Checked 2 entities: 1 passed, 1 failed, 0 errored. 2 violations (0 critical, 1 high, 1 medium, 0 low), 0 suppressed", summary.String())
+}
+
+func TestSummarizeOrganizations(t *testing.T) {
+	reports := newTestReports()
+	reports[0].Organization = "org-b"
+	reports[1].Organization = "org-a"
+
+	summaries := SummarizeOrganizations(reports, newTestRegistry())
+
+	assert.Len(t, summaries, 2)
+	assert.Equal(t, "org-a", summaries[0].Organization)
+	assert.Equal(t, 1, summaries[0].Passed)
+	assert.Equal(t, "org-b", summaries[1].Organization)
+	assert.Equal(t, 2, summaries[1].Total())
+
+	buffer := &bytes.Buffer{}
+	writer := &MarkdownWriter{Registry: newTestRegistry()}
+	assert.NoError(t, writer.Write(buffer, reports))
+	assert.Contains(t, buffer.String(), "| org-b | 1 | 0 | 1 | 0 | 2 | 0 |")
+}
+
+func TestRuleOutcomes_SubKeys(t *testing.T) {
+	reports := []types.CheckReport{
+		{
+			EntityType: "github_repository",
+			EntityId:   "docs",
+			Results:    map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Failed},
+			Errors: []types.CheckError{
+				{
+					Check:      types.GoCGuardrails,
+					Violations: map[string]string{"secret_scanning.push_protection": "push protection is not enabled"},
+				},
+			},
+		},
+	}
+
+	outcomes := ruleOutcomes(reports[0], newTestRegistry())
+
+	assert.Len(t, outcomes, 2)
+	assert.Equal(t, "delete_branch_on_merge", outcomes[0].Rule.Id)
+	assert.Equal(t, types.Passed, outcomes[0].Result)
+	assert.Equal(t, "secret_scanning.push_protection", outcomes[1].Rule.Id)
+	assert.Equal(t, types.High, outcomes[1].Rule.Severity)
+	assert.Equal(t, types.Failed, outcomes[1].Result)
+	assert.Equal(t, 1, Summarize(reports, newTestRegistry()).Violations[types.High])
+}
+
+func TestMarkdownWriter_Controls(t *testing.T) {
+	registry := newTestRegistry()
+	registry.MapControls(types.GoCGuardrails, "secret_scanning", types.Control{Id: "CIS 1.5.1", Url: "https://example.com/cis"})
+	buffer := &bytes.Buffer{}
+	writer := &MarkdownWriter{Registry: registry}
+
+	err := writer.Write(buffer, newTestReports())
+
+	assert.NoError(t, err)
+	assert.Contains(t, buffer.String(), "| Entity | Rule | Severity | Result | Controls | Message |")
+	assert.Contains(t, buffer.String(), "| docs | secret_scanning | High | Failed | [CIS 1.5.1](https://example.com/cis) | secret_scanning is not enabled. Expected it to be enabled |")
+	assert.Contains(t, buffer.String(), "| docs | unregistered | Medium | Failed |  | unregistered violation |")
+}
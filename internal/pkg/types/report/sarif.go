@@ -0,0 +1,246 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"sort"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "github-foundations-cli"
+	toolUri      = "https://github.com/FociSolutions/github-foundations-cli"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationUri string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	Id                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	ShortDescription     sarifMessage           `json:"shortDescription"`
+	DefaultConfiguration sarifRuleConfiguration `json:"defaultConfiguration"`
+	Properties           sarifRuleProperties    `json:"properties"`
+}
+
+type sarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProperties struct {
+	SecuritySeverity string   `json:"security-severity"`
+	Tags             []string `json:"tags"`
+}
+
+type sarifResult struct {
+	RuleId              string                 `json:"ruleId"`
+	RuleIndex           int                    `json:"ruleIndex"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Suppressions        []sarifSuppression     `json:"suppressions,omitempty"`
+	Properties          *sarifResultProperties `json:"properties,omitempty"`
+}
+
+type sarifResultProperties struct {
+	// Benchmark controls failed by the result, from every profile that reported it
+	Controls []types.Control `json:"controls"`
+}
+
+type sarifSuppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	Uri string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func sarifLevel(s types.Severity) string {
+	switch s {
+	case types.Critical, types.High:
+		return "error"
+	case types.Medium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// Scores used by GitHub code scanning to bucket security alerts
+func sarifSecuritySeverity(s types.Severity) string {
+	switch s {
+	case types.Critical:
+		return "9.5"
+	case types.High:
+		return "8.0"
+	case types.Medium:
+		return "5.5"
+	default:
+		return "2.0"
+	}
+}
+
+// SarifWriter writes every violation in the reports as a SARIF 2.1.0 result.
+// Rule metadata is looked up in the registry. Violations of unknown rules are reported with a medium severity.
+type SarifWriter struct {
+	Registry *types.CheckRegistry
+}
+
+func (s *SarifWriter) Write(w io.Writer, reports []types.CheckReport) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				InformationUri: toolUri,
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+	ruleIndexes := make(map[string]int)
+
+	for _, report := range reports {
+		// A rule included by several profiles is only reported once per entity
+		seen := make(map[string]bool)
+		controls := make(map[string][]types.Control)
+		for _, checkError := range report.Errors {
+			for key, c := range checkError.Controls {
+				controls[key] = append(controls[key], c...)
+			}
+		}
+		for _, checkError := range report.Errors {
+			// Sort the violation keys so the output is stable between runs
+			keys := make([]string, 0, len(checkError.Violations)+len(checkError.Suppressed))
+			for key := range checkError.Violations {
+				keys = append(keys, key)
+			}
+			for key := range checkError.Suppressed {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+
+				rule := lookupRule(s.Registry, key, report.EntityType)
+
+				index, ok := ruleIndexes[rule.Id]
+				if !ok {
+					index = len(run.Tool.Driver.Rules)
+					ruleIndexes[rule.Id] = index
+					run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+						Id:                   rule.Id,
+						Name:                 rule.Id,
+						ShortDescription:     sarifMessage{Text: rule.Description},
+						DefaultConfiguration: sarifRuleConfiguration{Level: sarifLevel(rule.Severity)},
+						Properties: sarifRuleProperties{
+							SecuritySeverity: sarifSecuritySeverity(rule.Severity),
+							Tags:             []string{"security", rule.EntityType},
+						},
+					})
+				}
+
+				message, violated := checkError.Violations[key]
+				var suppressions []sarifSuppression
+				if !violated {
+					message = "suppressed by baseline"
+					suppressions = []sarifSuppression{{Kind: "external", Justification: checkError.Suppressed[key]}}
+				}
+
+				var properties *sarifResultProperties
+				if len(controls[key]) > 0 {
+					properties = &sarifResultProperties{Controls: controls[key]}
+				}
+
+				qualifiedName := sarifQualifiedName(report)
+				run.Results = append(run.Results, sarifResult{
+					RuleId:       rule.Id,
+					RuleIndex:    index,
+					Level:        sarifLevel(rule.Severity),
+					Message:      sarifMessage{Text: fmt.Sprintf("%s: %s", report.EntityId, message)},
+					Suppressions: suppressions,
+					Properties:   properties,
+					Locations: []sarifLocation{
+						{
+							PhysicalLocation: sarifPhysicalLocation{
+								ArtifactLocation: sarifArtifactLocation{Uri: qualifiedName},
+							},
+							LogicalLocations: []sarifLogicalLocation{
+								{
+									Name:               report.EntityId,
+									FullyQualifiedName: qualifiedName,
+									Kind:               report.EntityType,
+								},
+							},
+						},
+					},
+					PartialFingerprints: map[string]string{
+						// The violation key tells apart the violations a rule reports through types.Violations
+						"entityRule/v1": fmt.Sprintf("%s:%s", qualifiedName, key),
+					},
+				})
+			}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifQualifiedName identifies the entity of a report. Entities of different organizations may share an id.
+func sarifQualifiedName(report types.CheckReport) string {
+	if report.Organization == "" {
+		return fmt.Sprintf("%s/%s", report.EntityType, report.EntityId)
+	}
+	return fmt.Sprintf("%s/%s/%s", report.EntityType, report.Organization, report.EntityId)
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"bytes"
+	"gh_foundations/internal/pkg/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func newTestRegistry() *types.CheckRegistry {
+	registry := types.NewCheckRegistry()
+	registry.Register(
+		types.Rule{
+			Id:          "secret_scanning",
+			Description: "Secret scanning is enabled",
+			Severity:    types.High,
+			EntityType:  "github_repository",
+			Evaluate:    func(entity any) error { return nil },
+		},
+		types.Rule{
+			Id:          "delete_branch_on_merge",
+			Description: "Head branches are deleted after merge",
+			Severity:    types.Low,
+			EntityType:  "github_repository",
+			Evaluate:    func(entity any) error { return nil },
+		},
+	)
+	registry.RegisterProfile(types.GoCGuardrails, "secret_scanning", "delete_branch_on_merge")
+	return registry
+}
+
+func newTestReports() []types.CheckReport {
+	return []types.CheckReport{
+		{
+			EntityType: "github_repository",
+			EntityId:   "docs",
+			Results:    map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Failed},
+			Errors: []types.CheckError{
+				{
+					Check: types.GoCGuardrails,
+					Violations: map[string]string{
+						"secret_scanning": "secret_scanning is not enabled. Expected it to be enabled",
+						"unregistered":    "unregistered violation",
+					},
+				},
+			},
+		},
+		{
+			EntityType: "github_repository",
+			EntityId:   "api",
+			Results:    map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Passed},
+			Errors:     []types.CheckError{},
+		},
+	}
+}
+
+func TestWriteSarif(t *testing.T) {
+	buffer := &bytes.Buffer{}
+
+	writer := &SarifWriter{Registry: newTestRegistry()}
+	err := writer.Write(buffer, newTestReports())
+
+	assert.NoError(t, err)
+	log := gjson.ParseBytes(buffer.Bytes())
+	assert.Equal(t, "2.1.0", log.Get("version").String())
+	assert.Equal(t, int64(2), log.Get("runs.0.tool.driver.rules.#").Int())
+	assert.Equal(t, "secret_scanning", log.Get("runs.0.tool.driver.rules.0.id").String())
+	assert.Equal(t, "8.0", log.Get("runs.0.tool.driver.rules.0.properties.security-severity").String())
+	assert.Equal(t, int64(2), log.Get("runs.0.results.#").Int())
+	assert.Equal(t, "error", log.Get("runs.0.results.0.level").String())
+	assert.Equal(t, "github_repository/docs", log.Get("runs.0.results.0.locations.0.logicalLocations.0.fullyQualifiedName").String())
+	assert.Equal(t, "warning", log.Get("runs.0.results.1.level").String())
+	assert.Equal(t, int64(1), log.Get("runs.0.results.1.ruleIndex").Int())
+}
+
+func TestWriteSarif_Fingerprints(t *testing.T) {
+	newReport := func(organization string) types.CheckReport {
+		return types.CheckReport{
+			EntityType:   "github_repository",
+			EntityId:     "api",
+			Organization: organization,
+			Results:      map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Failed},
+			Errors: []types.CheckError{
+				{
+					Check: types.GoCGuardrails,
+					Violations: map[string]string{
+						"secret_scanning.critical": "critical alerts are open",
+						"secret_scanning.high":     "high alerts are open",
+					},
+				},
+			},
+		}
+	}
+	buffer := &bytes.Buffer{}
+
+	writer := &SarifWriter{Registry: newTestRegistry()}
+	err := writer.Write(buffer, []types.CheckReport{newReport("acme"), newReport("globex")})
+
+	assert.NoError(t, err)
+	log := gjson.ParseBytes(buffer.Bytes())
+	assert.Equal(t, "github_repository/acme/api", log.Get("runs.0.results.0.locations.0.physicalLocation.artifactLocation.uri").String())
+	var fingerprints []string
+	for _, result := range log.Get("runs.0.results").Array() {
+		fingerprints = append(fingerprints, result.Get("partialFingerprints.entityRule/v1").String())
+	}
+	assert.Equal(t, []string{
+		"github_repository/acme/api:secret_scanning.critical",
+		"github_repository/acme/api:secret_scanning.high",
+		"github_repository/globex/api:secret_scanning.critical",
+		"github_repository/globex/api:secret_scanning.high",
+	}, fingerprints)
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"sort"
+	"strings"
+)
+
+// Summary aggregates the outcome of a check run.
+type Summary struct {
+	Entities   int
+	Passed     int
+	Failed     int
+	Errored    int
+	Violations map[types.Severity]int
+	Suppressed int
+}
+
+// Summarize counts entities by their overall result and violations by the severity of the rule that raised them.
+// An entity fails when any of its profiles fails and errors when any of its profiles errored.
+// Entities whose violations are all suppressed count as passed.
+func Summarize(reports []types.CheckReport, registry *types.CheckRegistry) Summary {
+	summary := Summary{Violations: make(map[types.Severity]int)}
+
+	for _, report := range reports {
+		summary.Entities++
+
+		result := types.Passed
+		for _, r := range report.Results {
+			if r == types.Errored {
+				result = types.Errored
+				break
+			} else if r == types.Failed {
+				result = types.Failed
+			}
+		}
+		switch result {
+		case types.Errored:
+			summary.Errored++
+		case types.Failed:
+			summary.Failed++
+		default:
+			summary.Passed++
+		}
+
+		// A rule included by several profiles is only counted once per entity
+		seen := make(map[string]bool)
+		for _, checkError := range report.Errors {
+			for key := range checkError.Violations {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				summary.Violations[lookupRule(registry, key, report.EntityType).Severity]++
+			}
+			for key := range checkError.Suppressed {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				summary.Suppressed++
+			}
+		}
+	}
+	return summary
+}
+
+// OrganizationSummary is the summary of the entities of a single organization
+type OrganizationSummary struct {
+	Organization string
+	Summary
+}
+
+// SummarizeOrganizations summarizes the reports of every organization, sorted by organization
+func SummarizeOrganizations(reports []types.CheckReport, registry *types.CheckRegistry) []OrganizationSummary {
+	byOrganization := make(map[string][]types.CheckReport)
+	for _, report := range reports {
+		byOrganization[report.Organization] = append(byOrganization[report.Organization], report)
+	}
+
+	summaries := make([]OrganizationSummary, 0, len(byOrganization))
+	for org, orgReports := range byOrganization {
+		summaries = append(summaries, OrganizationSummary{Organization: org, Summary: Summarize(orgReports, registry)})
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Organization < summaries[j].Organization })
+	return summaries
+}
+
+// Total returns the number of violations of every severity
+func (s Summary) Total() int {
+	return s.ViolationsAtOrAbove(types.Low)
+}
+
+// ViolationsAtOrAbove returns the number of violations with a severity greater than or equal to the threshold
+func (s Summary) ViolationsAtOrAbove(threshold types.Severity) int {
+	count := 0
+	for severity, n := range s.Violations {
+		if severity >= threshold {
+			count += n
+		}
+	}
+	return count
+}
+
+func (s Summary) String() string {
+	bySeverity := make([]string, 0, 4)
+	for _, severity := range []types.Severity{types.Critical, types.High, types.Medium, types.Low} {
+		bySeverity = append(bySeverity, fmt.Sprintf("%d %s", s.Violations[severity], strings.ToLower(severity.String())))
+	}
+	return fmt.Sprintf("Checked %d entities: %d passed, %d failed, %d errored. %d violations (%s), %d suppressed",
+		s.Entities, s.Passed, s.Failed, s.Errored, s.Total(), strings.Join(bySeverity, ", "), s.Suppressed)
+}
@@ -0,0 +1,27 @@
+package report
+
+import (
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"text/tabwriter"
+)
+
+// TableWriter writes one line per rule that did not pass, meant to be read in a terminal.
+type TableWriter struct {
+	Registry *types.CheckRegistry
+}
+
+func (t *TableWriter) Write(w io.Writer, reports []types.CheckReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ENTITY TYPE\tENTITY\tPROFILE\tRULE\tSEVERITY\tRESULT\tCONTROLS\tMESSAGE")
+	for _, report := range reports {
+		for _, outcome := range ruleOutcomes(report, t.Registry) {
+			if outcome.Result == types.Passed {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", report.EntityType, report.EntityId, outcome.Check, outcome.Rule.Id, outcome.Rule.Severity, outcome.Result, controlIds(outcome.Controls), outcome.Message)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gh_foundations/internal/pkg/types"
+	"gh_foundations/internal/pkg/types/report"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Scanner runs the checks and returns their reports. Errors do not discard the reports that could be collected.
+type Scanner func() ([]types.CheckReport, error)
+
+// A Server periodically runs the checks and serves the reports of the latest scan and their metrics
+type Server struct {
+	scan     Scanner
+	registry *types.CheckRegistry
+	now      func() time.Time
+
+	mu       sync.RWMutex
+	reports  []types.CheckReport
+	scanned  bool
+	lastScan time.Time
+	duration time.Duration
+	scanErr  error
+	scans    int
+	failures int
+}
+
+func NewServer(scan Scanner, registry *types.CheckRegistry) *Server {
+	return &Server{scan: scan, registry: registry, now: time.Now}
+}
+
+// Scan runs the checks once and replaces the reports of the previous scan
+func (s *Server) Scan() error {
+	start := s.now()
+	reports, err := s.scan()
+	duration := s.now().Sub(start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports = reports
+	s.scanned = true
+	s.lastScan = start
+	s.duration = duration
+	s.scanErr = err
+	s.scans++
+	if err != nil {
+		s.failures++
+	}
+	return err
+}
+
+// Run scans immediately and then at every interval until the context is done. Scan errors are passed to onError.
+func (s *Server) Run(ctx context.Context, interval time.Duration, onError func(err error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Scan(); err != nil && onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Handler serves the reports of the latest scan on /reports, the reports of an entity on /reports/{entity}
+// and the metrics of the latest scan on /metrics
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/reports", s.serveReports)
+	mux.HandleFunc("/reports/", s.serveEntityReports)
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	return mux
+}
+
+// latest returns the reports of the latest scan, or false when no scan completed yet
+func (s *Server) latest() ([]types.CheckReport, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.reports, s.scanned
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func (s *Server) serveReports(w http.ResponseWriter, r *http.Request) {
+	reports, ok := s.latest()
+	if !ok {
+		http.Error(w, "no scan completed yet", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, filterReports(reports, r.URL.Query().Get("organization"), r.URL.Query().Get("entity_type"), ""))
+}
+
+func (s *Server) serveEntityReports(w http.ResponseWriter, r *http.Request) {
+	reports, ok := s.latest()
+	if !ok {
+		http.Error(w, "no scan completed yet", http.StatusServiceUnavailable)
+		return
+	}
+	entity := strings.TrimPrefix(r.URL.Path, "/reports/")
+	matching := filterReports(reports, r.URL.Query().Get("organization"), r.URL.Query().Get("entity_type"), entity)
+	if len(matching) == 0 {
+		http.Error(w, fmt.Sprintf("no report of %q", entity), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, matching)
+}
+
+// filterReports keeps the reports matching the organization, entity type and entity id. Empty filters match every report.
+func filterReports(reports []types.CheckReport, organization string, entityType string, entityId string) []types.CheckReport {
+	matching := make([]types.CheckReport, 0, len(reports))
+	for _, report := range reports {
+		if (organization == "" || report.Organization == organization) &&
+			(entityType == "" || report.EntityType == entityType) &&
+			(entityId == "" || report.EntityId == entityId) {
+			matching = append(matching, report)
+		}
+	}
+	return matching
+}
+
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	fmt.Fprintf(w, "# HELP gh_foundations_scans_total Number of scans run\n# TYPE gh_foundations_scans_total counter\ngh_foundations_scans_total %d\n", s.scans)
+	fmt.Fprintf(w, "# HELP gh_foundations_scan_failures_total Number of scans that could not check every entity\n# TYPE gh_foundations_scan_failures_total counter\ngh_foundations_scan_failures_total %d\n", s.failures)
+	if !s.scanned {
+		return
+	}
+	success := 1
+	if s.scanErr != nil {
+		success = 0
+	}
+	fmt.Fprintf(w, "# HELP gh_foundations_scan_success Whether the latest scan checked every entity\n# TYPE gh_foundations_scan_success gauge\ngh_foundations_scan_success %d\n", success)
+	fmt.Fprintf(w, "# HELP gh_foundations_scan_duration_seconds Duration of the latest scan\n# TYPE gh_foundations_scan_duration_seconds gauge\ngh_foundations_scan_duration_seconds %g\n", s.duration.Seconds())
+	fmt.Fprintf(w, "# HELP gh_foundations_scan_timestamp_seconds Start of the latest scan, in seconds since the epoch\n# TYPE gh_foundations_scan_timestamp_seconds gauge\ngh_foundations_scan_timestamp_seconds %d\n", s.lastScan.Unix())
+	report.WriteMetrics(w, s.reports, s.registry)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"gh_foundations/internal/pkg/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestReports() []types.CheckReport {
+	return []types.CheckReport{
+		{
+			EntityType:   "github_organization",
+			EntityId:     "acme",
+			Organization: "acme",
+			Results:      map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Passed},
+			Errors:       []types.CheckError{},
+		},
+		{
+			EntityType:   "github_repository",
+			EntityId:     "api",
+			Organization: "acme",
+			Results:      map[types.CheckType]types.CheckResult{types.GoCGuardrails: types.Failed},
+			Errors: []types.CheckError{
+				{Check: types.GoCGuardrails, Violations: map[string]string{"secret_scanning": "secret_scanning is not enabled. Expected it to be enabled"}},
+			},
+		},
+	}
+}
+
+func newTestServer(t *testing.T, scanErr error) (*Server, *httptest.Server) {
+	registry := types.NewCheckRegistry()
+	assert.NoError(t, registry.Register(types.Rule{Id: "secret_scanning", Severity: types.High, EntityType: "github_repository", Evaluate: func(any) error { return nil }}))
+	assert.NoError(t, registry.RegisterProfile(types.GoCGuardrails, "secret_scanning"))
+
+	s := NewServer(func() ([]types.CheckReport, error) { return newTestReports(), scanErr }, registry)
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	calls := 0
+	s.now = func() time.Time {
+		calls++
+		return start.Add(time.Duration(calls-1) * 1500 * time.Millisecond)
+	}
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(httpServer.Close)
+	return s, httpServer
+}
+
+func get(t *testing.T, url string) (int, string) {
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestServer_Reports(t *testing.T) {
+	s, httpServer := newTestServer(t, nil)
+
+	status, _ := get(t, httpServer.URL+"/reports")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+
+	assert.NoError(t, s.Scan())
+
+	status, body := get(t, httpServer.URL+"/reports")
+	assert.Equal(t, http.StatusOK, status)
+	var reports []types.CheckReport
+	assert.NoError(t, json.Unmarshal([]byte(body), &reports))
+	assert.Len(t, reports, 2)
+
+	_, body = get(t, httpServer.URL+"/reports?entity_type=github_repository")
+	assert.NoError(t, json.Unmarshal([]byte(body), &reports))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, types.Failed, reports[0].Results[types.GoCGuardrails])
+
+	status, body = get(t, httpServer.URL+"/reports/acme")
+	assert.Equal(t, http.StatusOK, status)
+	assert.NoError(t, json.Unmarshal([]byte(body), &reports))
+	assert.Len(t, reports, 1)
+	assert.Equal(t, "github_organization", reports[0].EntityType)
+
+	status, _ = get(t, httpServer.URL+"/reports/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s, httpServer := newTestServer(t, errors.New("unable to get teams of \"acme\""))
+
+	_, body := get(t, httpServer.URL+"/metrics")
+	assert.Contains(t, body, "gh_foundations_scans_total 0\n")
+	assert.NotContains(t, body, "gh_foundations_scan_success")
+
+	assert.Error(t, s.Scan())
+
+	_, body = get(t, httpServer.URL+"/metrics")
+	assert.Contains(t, body, "gh_foundations_scans_total 1\n")
+	assert.Contains(t, body, "gh_foundations_scan_failures_total 1\n")
+	assert.Contains(t, body, "gh_foundations_scan_success 0\n")
+	assert.Contains(t, body, "gh_foundations_scan_duration_seconds 1.5\n")
+	assert.Contains(t, body, "gh_foundations_scan_timestamp_seconds 1792227600\n")
+	assert.Contains(t, body, `gh_foundations_rule_entities{organization="acme",profile="GoCGuardrails",rule="secret_scanning",severity="high",result="failed"} 1`)
+}
@@ -25,11 +25,14 @@ type Repository struct {
 	ProtectedBranches 			[]string	`mapstructure:"protected_branches"`
 	RequiresWebCommitSignOff 	bool 		`mapstructure:"requires_web_commit_signing"`
 	Topics 						[]string	`mapstructure:"topics"`
+	LicenseTemplate 			string		`mapstructure:"license_template"`
 }
 
 
 type OrgProjectSet struct {
 	RepositorySets 		map[string]githubfoundations.RepositorySetInput
+	// Path of the HCL file declaring the repository set, by project
+	Paths 				map[string]string
 }
 
 type OrgSet struct {
@@ -45,6 +48,7 @@ func (org OrgSet) WithGHASEnabled() OrgSet {
 	for orgName, projects := range org.OrgProjectSets {
 		ptrOrgProjectSet := new(OrgProjectSet)
 		ptrOrgProjectSet.RepositorySets = make(map[string]githubfoundations.RepositorySetInput)
+		ptrOrgProjectSet.Paths = projects.Paths
 		reposWithGHAS.OrgProjectSets[orgName] = *ptrOrgProjectSet
 
 		for projectName, repoSet := range projects.RepositorySets {
@@ -80,5 +84,6 @@ func (repo *Repository) GetRepositoryInput() githubfoundations.RepositoryInput {
 		ProtectedBranches: repo.ProtectedBranches,
 		RequiresWebCommitSignOff: repo.RequiresWebCommitSignOff,
 		Topics: repo.Topics,
+		LicenseTemplate: repo.LicenseTemplate,
 	}
 }